@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"strconv"
@@ -22,6 +23,7 @@ func main() {
 		Url:			os.Getenv("PROXMOX_URL"),
 		Username:		os.Getenv("PROXMOX_USERNAME"),
 		Password:		os.Getenv("PROXMOX_PASSWORD"),
+		TotpCode:		os.Getenv("PROXMOX_OTP"),
 		TlsInsecure:	!*insecure,
 		}, true)
 	if err != nil {
@@ -152,6 +154,13 @@ func main() {
 		failError(err)
 		log.Println("Keys sent")
 
+	case "screenshot":
+		vmr = proxmox.NewVmRef(vmid)
+		screenshot, err := c.Screenshot(vmr)
+		failError(err)
+		failError(ioutil.WriteFile(flag.Args()[2], screenshot, 0644))
+		log.Println("Screenshot saved to " + flag.Args()[2])
+
 	default:
 		fmt.Printf("unknown action, try start|stop vmid")
 	}
@@ -0,0 +1,194 @@
+package proxmox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ApiToken - API token of a user, Secret is only known right after creation.
+type ApiToken struct {
+	TokenId string // user@realm!name
+	Secret  string
+	Comment string
+	Expire  int // epoch, 0 for no expiration
+	Privsep bool
+}
+
+// splitTokenId - user and token name of `user@realm!name`.
+func splitTokenId(tokenId string) (userid string, name string, err error) {
+	parts := strings.SplitN(tokenId, "!", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("malformed token id '%s', expected user@realm!name", tokenId)
+	}
+	return parts[0], parts[1], nil
+}
+
+// ChangePassword - Change the password of userid. The password of the configuration is sent as
+// confirmation and replaced when the authenticated user changes its own password, so later logins keep working.
+func (c *Client) ChangePassword(userid string, newPassword string) (err error) {
+	params := map[string]interface{}{
+		"userid":   userid,
+		"password": newPassword,
+	}
+	if c.configuration.Password != "" {
+		params["confirmation-password"] = c.configuration.Password
+	}
+	reqbody := ParamsToBody(params)
+	olddebug := *Debug
+	*Debug = false // don't share passwords in debug log
+	_, err = c.session.Put("/access/password", nil, nil, &reqbody)
+	*Debug = olddebug
+	if err != nil {
+		return err
+	}
+	if userid == c.configuration.Username {
+		c.configuration.Password = newPassword
+	}
+	return nil
+}
+
+// GetApiToken - Get the settings of an API token.
+func (c *Client) GetApiToken(tokenId string) (token *ApiToken, err error) {
+	userid, name, err := splitTokenId(tokenId)
+	if err != nil {
+		return nil, err
+	}
+	tokenInfo, err := getApiData[map[string]interface{}](c, ApiPath("access", "users", userid, "token", name))
+	if err != nil {
+		return nil, err
+	}
+	return &ApiToken{
+		TokenId: tokenId,
+		Comment: GetString(tokenInfo, "comment"),
+		Expire:  GetIntDefault(tokenInfo, "expire", 0),
+		Privsep: Itob(GetIntDefault(tokenInfo, "privsep", 1)),
+	}, nil
+}
+
+// CreateApiToken - Create the API token token.TokenId, returns it with its secret.
+func (c *Client) CreateApiToken(token ApiToken) (created *ApiToken, err error) {
+	userid, name, err := splitTokenId(token.TokenId)
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]interface{}{
+		"privsep": token.Privsep,
+	}
+	if token.Comment != "" {
+		params["comment"] = token.Comment
+	}
+	if token.Expire > 0 {
+		params["expire"] = token.Expire
+	}
+	olddebug := *Debug
+	*Debug = false // don't share secrets in debug log
+	reqbody := ParamsToBody(params)
+	resp, err := c.session.Post(ApiPath("access", "users", userid, "token", name), nil, nil, &reqbody)
+	*Debug = olddebug
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var response ApiResponse[map[string]interface{}]
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return nil, err
+	}
+	secret := GetString(response.Data, "value")
+	if secret == "" {
+		return nil, errors.New("API token secret not readable")
+	}
+	created = &token
+	created.Secret = secret
+	return created, nil
+}
+
+// DeleteApiToken - Remove an API token.
+func (c *Client) DeleteApiToken(tokenId string) (err error) {
+	userid, name, err := splitTokenId(tokenId)
+	if err != nil {
+		return err
+	}
+	_, err = c.session.Delete(ApiPath("access", "users", userid, "token", name), nil, nil)
+	return
+}
+
+// copyTokenAcls - grant newTokenId the ACLs of tokenId, needed by privilege separated tokens.
+func (c *Client) copyTokenAcls(tokenId string, newTokenId string) (err error) {
+	acls, err := getApiData[[]map[string]interface{}](c, "/access/acl")
+	if err != nil {
+		return err
+	}
+	for _, acl := range acls {
+		if GetString(acl, "type") != "token" || GetString(acl, "ugid") != tokenId {
+			continue
+		}
+		reqbody := ParamsToBody(map[string]interface{}{
+			"path":      GetString(acl, "path"),
+			"roles":     GetString(acl, "roleid"),
+			"tokens":    newTokenId,
+			"propagate": GetIntDefault(acl, "propagate", 1),
+		})
+		_, err = c.session.Put("/access/acl", nil, nil, &reqbody)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RotateOwnToken - Replace the API token the client is authenticated with by a new token newName
+// of the same user, with the same settings and ACLs. The new token is checked before the old one
+// is deleted and the client switches to it, the returned token holds the secret to persist.
+// Copying the ACLs of privilege separated tokens requires Permissions.Modify on their paths.
+func (c *Client) RotateOwnToken(newName string) (token *ApiToken, err error) {
+	oldTokenId := c.configuration.TokenId
+	if oldTokenId == "" {
+		return nil, errors.New("client is not authenticated with an API token")
+	}
+	userid, _, err := splitTokenId(oldTokenId)
+	if err != nil {
+		return nil, err
+	}
+	oldToken, err := c.GetApiToken(oldTokenId)
+	if err != nil {
+		return nil, err
+	}
+	newToken := *oldToken
+	newToken.TokenId = userid + "!" + newName
+	token, err = c.CreateApiToken(newToken)
+	if err != nil {
+		return nil, err
+	}
+	if token.Privsep {
+		err = c.copyTokenAcls(oldTokenId, token.TokenId)
+	}
+	if err == nil {
+		// Check the new token before dropping the old one.
+		newSession := *c.session
+		newSession.SetApiToken(token.TokenId, token.Secret)
+		var response ApiResponse[json.RawMessage]
+		_, err = newSession.GetJSON("/version", nil, nil, &response)
+		if err == nil {
+			err = response.Err()
+		}
+	}
+	if err != nil {
+		cleanupErr := c.DeleteApiToken(token.TokenId)
+		if cleanupErr != nil {
+			return nil, fmt.Errorf("new token '%s' not usable, kept '%s': %w; the new token could not be deleted: %w", token.TokenId, oldTokenId, err, cleanupErr)
+		}
+		return nil, fmt.Errorf("new token '%s' not usable, kept '%s': %w", token.TokenId, oldTokenId, err)
+	}
+
+	c.session.SetApiToken(token.TokenId, token.Secret)
+	c.configuration.TokenId = token.TokenId
+	c.configuration.TokenSecret = token.Secret
+	err = c.DeleteApiToken(oldTokenId)
+	if err != nil {
+		return token, fmt.Errorf("switched to token '%s' but could not delete '%s': %w", token.TokenId, oldTokenId, err)
+	}
+	return token, nil
+}
@@ -0,0 +1,206 @@
+package proxmox
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ACME plugin types, dns plugins validate DNS-01 challenges through the API of a DNS provider.
+const (
+	AcmePluginDns        = "dns"
+	AcmePluginStandalone = "standalone"
+)
+
+// AcmePluginData - credentials and settings of a DNS plugin, keyed by the variables of its
+// provider (e.g. CF_Token for the `cf` API). Values are redacted when printed.
+type AcmePluginData map[string]string
+
+func (data AcmePluginData) String() string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	redacted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		redacted = append(redacted, key+"=<redacted>")
+	}
+	return "map[" + strings.Join(redacted, " ") + "]"
+}
+
+func (data AcmePluginData) GoString() string {
+	return data.String()
+}
+
+// encode - KEY=value lines, base64 encoded as expected by the API.
+func (data AcmePluginData) encode() string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var lines strings.Builder
+	for _, key := range keys {
+		lines.WriteString(key + "=" + data[key] + "\n")
+	}
+	return base64.StdEncoding.EncodeToString([]byte(lines.String()))
+}
+
+// parseAcmePluginData - data of a plugin, returned by the API as KEY=value lines.
+func parseAcmePluginData(lines string) AcmePluginData {
+	data := AcmePluginData{}
+	for _, line := range strings.Split(lines, "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+		if found {
+			data[key] = value
+		}
+	}
+	return data
+}
+
+// AcmePlugin - ACME challenge plugin of the cluster. Api is the DNS provider (cf, ovh, aws...),
+// Nodes restricts the plugin to some nodes (all when empty) and ValidationDelay is the number
+// of seconds to wait for DNS propagation before validation.
+type AcmePlugin struct {
+	Id              string
+	Type            string
+	Api             string
+	Data            AcmePluginData
+	Disable         bool
+	Nodes           []string
+	ValidationDelay int
+}
+
+func acmePluginFromApi(pluginInfo map[string]interface{}) AcmePlugin {
+	plugin := AcmePlugin{
+		Id:              GetString(pluginInfo, "plugin"),
+		Type:            GetString(pluginInfo, "type"),
+		Api:             GetString(pluginInfo, "api"),
+		Data:            parseAcmePluginData(GetString(pluginInfo, "data")),
+		Disable:         GetIntDefault(pluginInfo, "disable", 0) == 1,
+		ValidationDelay: GetIntDefault(pluginInfo, "validation-delay", 0),
+	}
+	if nodes := GetString(pluginInfo, "nodes"); nodes != "" {
+		plugin.Nodes = strings.Split(nodes, ",")
+	}
+	return plugin
+}
+
+// Validate - check the plugin before sending it.
+func (plugin AcmePlugin) Validate() error {
+	if plugin.Id == "" {
+		return errors.New("ACME plugin id is required")
+	}
+	switch plugin.Type {
+	case AcmePluginDns:
+		if plugin.Api == "" {
+			return fmt.Errorf("ACME plugin '%s' of type dns needs an api", plugin.Id)
+		}
+	case AcmePluginStandalone:
+	default:
+		return fmt.Errorf("ACME plugin type can only be one of the following values: %s, %s", AcmePluginDns, AcmePluginStandalone)
+	}
+	if plugin.ValidationDelay < 0 || plugin.ValidationDelay > 172800 {
+		return errors.New("ACME plugin validation delay must be between 0 and 172800 seconds")
+	}
+	return nil
+}
+
+// params - API parameters of the plugin, without id and type.
+func (plugin AcmePlugin) params() map[string]interface{} {
+	params := map[string]interface{}{
+		"disable": plugin.Disable,
+	}
+	if plugin.Type == AcmePluginDns {
+		params["api"] = plugin.Api
+		params["data"] = plugin.Data.encode()
+	}
+	if len(plugin.Nodes) > 0 {
+		params["nodes"] = strings.Join(plugin.Nodes, ",")
+	}
+	if plugin.ValidationDelay > 0 {
+		params["validation-delay"] = plugin.ValidationDelay
+	}
+	return params
+}
+
+// ListAcmePlugins - ACME plugins of the cluster, with their credentials.
+func (c *Client) ListAcmePlugins() (plugins []AcmePlugin, err error) {
+	olddebug := *Debug
+	*Debug = false // don't share credentials in debug log
+	list, err := getApiData[[]map[string]interface{}](c, "/cluster/acme/plugins")
+	*Debug = olddebug
+	if err != nil {
+		return nil, err
+	}
+	for _, pluginInfo := range list {
+		plugins = append(plugins, acmePluginFromApi(pluginInfo))
+	}
+	return
+}
+
+// GetAcmePlugin - ACME plugin id with its credentials.
+func (c *Client) GetAcmePlugin(id string) (plugin *AcmePlugin, err error) {
+	olddebug := *Debug
+	*Debug = false // don't share credentials in debug log
+	pluginInfo, err := getApiData[map[string]interface{}](c, ApiPath("cluster", "acme", "plugins", id))
+	*Debug = olddebug
+	if err != nil {
+		return nil, err
+	}
+	found := acmePluginFromApi(pluginInfo)
+	found.Id = id
+	return &found, nil
+}
+
+// CreateAcmePlugin - Add an ACME plugin to the cluster.
+func (c *Client) CreateAcmePlugin(plugin AcmePlugin) (err error) {
+	err = plugin.Validate()
+	if err != nil {
+		return err
+	}
+	params := plugin.params()
+	params["id"] = plugin.Id
+	params["type"] = plugin.Type
+	reqbody := ParamsToBody(params)
+	olddebug := *Debug
+	*Debug = false // don't share credentials in debug log
+	_, err = c.session.Post("/cluster/acme/plugins", nil, nil, &reqbody)
+	*Debug = olddebug
+	return
+}
+
+// UpdateAcmePlugin - Replace the settings of an existing ACME plugin, nodes and validation delay
+// are reset to their defaults when unset.
+func (c *Client) UpdateAcmePlugin(plugin AcmePlugin) (err error) {
+	err = plugin.Validate()
+	if err != nil {
+		return err
+	}
+	params := plugin.params()
+	var deletes []string
+	if len(plugin.Nodes) == 0 {
+		deletes = append(deletes, "nodes")
+	}
+	if plugin.ValidationDelay == 0 {
+		deletes = append(deletes, "validation-delay")
+	}
+	if len(deletes) > 0 {
+		params["delete"] = strings.Join(deletes, ",")
+	}
+	reqbody := ParamsToBody(params)
+	olddebug := *Debug
+	*Debug = false // don't share credentials in debug log
+	_, err = c.session.Put(ApiPath("cluster", "acme", "plugins", plugin.Id), nil, nil, &reqbody)
+	*Debug = olddebug
+	return
+}
+
+// DeleteAcmePlugin - Remove an ACME plugin.
+func (c *Client) DeleteAcmePlugin(id string) (err error) {
+	_, err = c.session.Delete(ApiPath("cluster", "acme", "plugins", id), nil, nil)
+	return
+}
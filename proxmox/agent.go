@@ -0,0 +1,260 @@
+package proxmox
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AgentExecPollInterval - time between exec-status checks of a guest process.
+const AgentExecPollInterval = 500 * time.Millisecond
+
+// GuestExecStatus - state of a process started with the QEMU guest agent.
+type GuestExecStatus struct {
+	Exited       bool   `json:"exited"`
+	ExitCode     int    `json:"exitcode"`
+	Signal       int    `json:"signal"`
+	Stdout       string `json:"out-data"`
+	Stderr       string `json:"err-data"`
+	OutTruncated bool   `json:"out-truncated"`
+	ErrTruncated bool   `json:"err-truncated"`
+}
+
+// GuestExecResult - outcome of RunInGuestAndWait. Stdout and Stderr are the whole output of the
+// process, the guest agent only returns it once the process exited.
+type GuestExecResult struct {
+	Pid      int
+	ExitCode int
+	Stdout   string
+	Stderr   string
+	// The deadline passed before the process exited, the process is left running in the guest
+	// and its output is not collected.
+	TimedOut bool
+}
+
+// agentUrl - URL of a guest agent command.
+func agentUrl(vmr *VmRef, command string) string {
+	return ApiPath("nodes", vmr.node, "qemu", vmr.vmId, "agent", command)
+}
+
+// AgentExec - start a process in the guest, stdin is passed as input data when not empty.
+func (c *Client) AgentExec(vmr *VmRef, command []string, stdin string) (pid int, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return 0, err
+	}
+	if len(command) == 0 {
+		return 0, errors.New("guest exec needs a command")
+	}
+	params := map[string]interface{}{}
+	version, err := c.GetVersion()
+	if err != nil {
+		return 0, err
+	}
+	// PVE 7 takes the command and its arguments as a list, earlier releases as one string.
+	if version.AtLeast(7, 0) {
+		params["command"] = command
+	} else {
+		params["command"] = strings.Join(command, " ")
+	}
+	if stdin != "" {
+		params["input-data"] = stdin
+	}
+	reqbody := ParamsToBody(params)
+	resp, err := c.session.Post(agentUrl(vmr, "exec"), nil, nil, &reqbody)
+	if err != nil {
+		return 0, err
+	}
+	execResponse := ResponseJSON(resp)
+	execData, ok := execResponse["data"].(map[string]interface{})
+	if !ok {
+		return 0, &ErrUnexpectedResponse{"agent exec response not readable", execResponse}
+	}
+	return GetInt(execData, "pid")
+}
+
+// AgentExecStatus - state and output of a process started with AgentExec.
+func (c *Client) AgentExecStatus(vmr *VmRef, pid int) (status *GuestExecStatus, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("pid", fmt.Sprintf("%d", pid))
+	data, err := getApiDataWithParams[*struct {
+		Exited       FlexBool `json:"exited"`
+		ExitCode     FlexInt  `json:"exitcode"`
+		Signal       FlexInt  `json:"signal"`
+		Stdout       string   `json:"out-data"`
+		Stderr       string   `json:"err-data"`
+		OutTruncated FlexBool `json:"out-truncated"`
+		ErrTruncated FlexBool `json:"err-truncated"`
+	}](c, agentUrl(vmr, "exec-status"), params)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, errors.New("agent exec-status not readable")
+	}
+	return &GuestExecStatus{
+		Exited:       bool(data.Exited),
+		ExitCode:     int(data.ExitCode),
+		Signal:       int(data.Signal),
+		Stdout:       data.Stdout,
+		Stderr:       data.Stderr,
+		OutTruncated: bool(data.OutTruncated),
+		ErrTruncated: bool(data.ErrTruncated),
+	}, nil
+}
+
+// RunInGuestAndWait - run cmd with args in the guest and wait for it to exit, at most timeout.
+// Output is not streamed: the guest agent returns out-data and err-data only once the process
+// exited, so it is all in the result. On timeout the result has TimedOut set and no error, the
+// process keeps running in the guest and its output can still be read with AgentExecStatus.
+func (c *Client) RunInGuestAndWait(vmr *VmRef, cmd string, args []string, stdin string, timeout time.Duration) (result *GuestExecResult, err error) {
+	pid, err := c.AgentExec(vmr, append([]string{cmd}, args...), stdin)
+	if err != nil {
+		return nil, err
+	}
+	result = &GuestExecResult{Pid: pid}
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := c.AgentExecStatus(vmr, pid)
+		if err != nil {
+			return nil, err
+		}
+		if status.Exited {
+			result.ExitCode = status.ExitCode
+			result.Stdout = status.Stdout
+			result.Stderr = status.Stderr
+			return result, nil
+		}
+		if time.Now().After(deadline) {
+			result.TimedOut = true
+			return result, nil
+		}
+		time.Sleep(AgentExecPollInterval)
+	}
+}
+
+// WaitForAgent - wait until the guest agent answers a ping, at most timeout.
+func (c *Client) WaitForAgent(vmr *VmRef, timeout time.Duration) (err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err = c.session.Post(agentUrl(vmr, "ping"), nil, nil, nil)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("guest agent of VM %d not responding after %s: %w", vmr.vmId, timeout, err)
+		}
+		time.Sleep(TaskStatusCheckInterval * time.Second)
+	}
+}
+
+// GuestOsInfo - operating system of a guest as reported by its agent, AgentVersion is the
+// version of the QEMU guest agent itself.
+type GuestOsInfo struct {
+	HostName      string `json:"host-name"`
+	Id            string `json:"id"`
+	Name          string `json:"name"`
+	PrettyName    string `json:"pretty-name"`
+	Version       string `json:"version"`
+	VersionId     string `json:"version-id"`
+	Variant       string `json:"variant"`
+	KernelRelease string `json:"kernel-release"`
+	KernelVersion string `json:"kernel-version"`
+	Machine       string `json:"machine"`
+	AgentVersion  string `json:"agent-version"`
+}
+
+// GetGuestOsInfo - Get the OS, kernel, host name and agent version of a running VM from its guest agent.
+func (c *Client) GetGuestOsInfo(vmr *VmRef) (info *GuestOsInfo, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return nil, err
+	}
+	osInfo, err := agentResult[GuestOsInfo](c, vmr, "get-osinfo")
+	if err != nil {
+		return nil, err
+	}
+	info = &osInfo
+	hostName, err := agentResult[struct {
+		HostName string `json:"host-name"`
+	}](c, vmr, "get-host-name")
+	if err != nil {
+		return nil, err
+	}
+	info.HostName = hostName.HostName
+	agentInfo, err := agentResult[struct {
+		Version string `json:"version"`
+	}](c, vmr, "info")
+	if err != nil {
+		return nil, err
+	}
+	info.AgentVersion = agentInfo.Version
+	return
+}
+
+// GetGuestTime - Get the clock of a running VM from its guest agent.
+func (c *Client) GetGuestTime(vmr *VmRef) (guestTime time.Time, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	nanoseconds, err := agentResult[FlexInt](c, vmr, "get-time")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(nanoseconds)), nil
+}
+
+// GetGuestClockDrift - how far the guest clock is ahead of the local clock (negative when behind),
+// the request round trip is compensated by comparing with its midpoint.
+func (c *Client) GetGuestClockDrift(vmr *VmRef) (drift time.Duration, err error) {
+	before := time.Now()
+	guestTime, err := c.GetGuestTime(vmr)
+	if err != nil {
+		return 0, err
+	}
+	after := time.Now()
+	return guestTime.Sub(before.Add(after.Sub(before) / 2)), nil
+}
+
+// GuestTimezone - timezone of a guest, Offset is in seconds east of UTC.
+type GuestTimezone struct {
+	Zone   string  `json:"zone"`
+	Offset FlexInt `json:"offset"`
+}
+
+// GetGuestTimezone - Get the timezone of a running VM from its guest agent.
+func (c *Client) GetGuestTimezone(vmr *VmRef) (timezone *GuestTimezone, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return nil, err
+	}
+	result, err := agentResult[GuestTimezone](c, vmr, "get-timezone")
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SyncGuestTime - Set the clock of a running Linux VM from its RTC, which QEMU keeps on the host
+// time. Proxmox does not expose the agent set-time command, `hwclock --hctosys` is run in the guest instead.
+func (c *Client) SyncGuestTime(vmr *VmRef) (err error) {
+	result, err := c.RunInGuestAndWait(vmr, "hwclock", []string{"--hctosys"}, "", time.Minute)
+	if err != nil {
+		return err
+	}
+	if result.TimedOut || result.ExitCode != 0 {
+		return fmt.Errorf("hwclock failed in VM %d: %s", vmr.vmId, strings.TrimSpace(result.Stderr))
+	}
+	return nil
+}
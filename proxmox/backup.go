@@ -0,0 +1,168 @@
+package proxmox
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Backup modes of vzdump.
+const (
+	BackupModeSnapshot = "snapshot"
+	BackupModeSuspend  = "suspend"
+	BackupModeStop     = "stop"
+)
+
+// DefaultBackupNodeConcurrency - backups running at once on a node when the configuration does not set it.
+const DefaultBackupNodeConcurrency = 1
+
+// GuestSelector - guests matching every non empty criterion: one of VmIds, in Pool, on Node,
+// of Type (qemu or lxc) and carrying all of Tags. Templates are only selected with Templates.
+type GuestSelector struct {
+	VmIds     []int
+	Pool      string
+	Node      string
+	Type      string
+	Tags      []string
+	Templates bool
+}
+
+// Match - whether the guest is selected.
+func (selector GuestSelector) Match(vm VmResource) bool {
+	if len(selector.VmIds) > 0 {
+		selected := false
+		for _, vmid := range selector.VmIds {
+			selected = selected || vmid == int(vm.VmId)
+		}
+		if !selected {
+			return false
+		}
+	}
+	if (selector.Pool != "" && vm.Pool != selector.Pool) ||
+		(selector.Node != "" && vm.Node != selector.Node) ||
+		(selector.Type != "" && vm.Type != selector.Type) ||
+		(bool(vm.Template) && !selector.Templates) {
+		return false
+	}
+	tags := splitTags(vm.Tags)
+	for _, tag := range selector.Tags {
+		if !inArray(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectGuests - guests of the cluster matching selector, sorted by vmid.
+func (c *Client) SelectGuests(selector GuestSelector) (vms []VmResource, err error) {
+	resources, err := c.GetVmResources()
+	if err != nil {
+		return nil, err
+	}
+	for _, vm := range resources {
+		if selector.Match(vm) {
+			vms = append(vms, vm)
+		}
+	}
+	sort.Slice(vms, func(i, j int) bool { return vms[i].VmId < vms[j].VmId })
+	return
+}
+
+// BackupResult - outcome of the backup of one guest of BackupGuests.
+type BackupResult struct {
+	VmRef      *VmRef
+	ExitStatus string
+	Err        error
+}
+
+func checkBackupMode(mode string) error {
+	if !inArray([]string{BackupModeSnapshot, BackupModeSuspend, BackupModeStop}, mode) {
+		return fmt.Errorf("backup mode can only be one of the following values: %s, %s, %s", BackupModeSnapshot, BackupModeSuspend, BackupModeStop)
+	}
+	return nil
+}
+
+// BackupVm - Back up a guest to storage with vzdump, mode is one of the BackupMode constants.
+func (c *Client) BackupVm(vmr *VmRef, storage string, mode string) (exitStatus string, err error) {
+	err = checkBackupMode(mode)
+	if err != nil {
+		return "", err
+	}
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return "", err
+	}
+	reqbody := ParamsToBody(map[string]interface{}{
+		"vmid":    vmr.vmId,
+		"storage": storage,
+		"mode":    mode,
+	})
+	url := ApiPath("nodes", vmr.node, "vzdump")
+	resp, err := c.session.Post(url, nil, nil, &reqbody)
+	if err == nil {
+		taskResponse := ResponseJSON(resp)
+		exitStatus, err = c.WaitForCompletion(taskResponse)
+	}
+	return
+}
+
+// BackupGuests - Back up the guests matching selector, at most concurrency backups in parallel
+// over the cluster and BackupNodeConcurrency per node so a node's storage is not saturated.
+// Results are sorted by vmid, an error lists the backups that failed.
+func (c *Client) BackupGuests(selector GuestSelector, storage string, mode string, concurrency int) (results []BackupResult, err error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	err = checkBackupMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	nodeConcurrency := c.configuration.BackupNodeConcurrency
+	if nodeConcurrency < 1 {
+		nodeConcurrency = DefaultBackupNodeConcurrency
+	}
+	vms, err := c.SelectGuests(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	results = make([]BackupResult, len(vms))
+	slots := make(chan struct{}, concurrency)
+	nodeSlots := map[string]chan struct{}{}
+	var wg sync.WaitGroup
+	for ii, vm := range vms {
+		vmr := NewVmRef(int(vm.VmId))
+		vmr.SetNode(vm.Node)
+		vmr.SetVmType(vm.Type)
+		results[ii].VmRef = vmr
+		if nodeSlots[vm.Node] == nil {
+			nodeSlots[vm.Node] = make(chan struct{}, nodeConcurrency)
+		}
+		wg.Add(1)
+		go func(result *BackupResult, nodeSlot chan struct{}) {
+			defer wg.Done()
+			// Node slot first, waiting for a busy node must not hold a cluster slot.
+			nodeSlot <- struct{}{}
+			defer func() { <-nodeSlot }()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			result.ExitStatus, result.Err = c.BackupVm(result.VmRef, storage, mode)
+			if result.Err == nil && result.ExitStatus != exitStatusSuccess {
+				result.Err = fmt.Errorf("backup of %d failed: %s", result.VmRef.vmId, result.ExitStatus)
+			}
+		}(&results[ii], nodeSlots[vm.Node])
+	}
+	wg.Wait()
+
+	var failed []string
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, fmt.Sprintf("%d: %s", result.VmRef.vmId, result.Err))
+		}
+	}
+	if len(failed) > 0 {
+		return results, fmt.Errorf("%d of %d backups failed: %s", len(failed), len(results), strings.Join(failed, "; "))
+	}
+	return
+}
@@ -0,0 +1,155 @@
+package proxmox
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BatchResult - outcome of one VM creation of CreateVmBatch, Index is the position of its config.
+type BatchResult struct {
+	Index int
+	VmRef *VmRef
+	Err   error
+	// The VM was created then deleted because another creation of the batch failed.
+	RolledBack  bool
+	RollbackErr error
+}
+
+// allocateVmIds - count free vmids starting from the next free one, allocated client side
+// so parallel creations do not race on /cluster/nextid.
+func (c *Client) allocateVmIds(count int) (vmids []int, err error) {
+	next, err := c.GetNextID(0)
+	if err != nil {
+		return nil, err
+	}
+	index, err := c.GetVmIndex()
+	if err != nil {
+		return nil, err
+	}
+	for vmid := next; len(vmids) < count; vmid++ {
+		if _, used := index[vmid]; !used {
+			vmids = append(vmids, vmid)
+		}
+	}
+	return
+}
+
+// CreateVmBatch - Create VMs on node from configs, at most concurrency creations in parallel.
+// Results are in the order of configs. With rollbackOnFailure, if any creation fails the VMs
+// created successfully are deleted.
+func (c *Client) CreateVmBatch(node string, configs []ConfigQemu, concurrency int, rollbackOnFailure bool) (results []BatchResult, err error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	vmids, err := c.allocateVmIds(len(configs))
+	if err != nil {
+		return nil, err
+	}
+
+	results = make([]BatchResult, len(configs))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for ii := range configs {
+		vmr := NewVmRef(vmids[ii])
+		vmr.SetNode(node)
+		results[ii] = BatchResult{Index: ii, VmRef: vmr}
+		wg.Add(1)
+		go func(result *BatchResult, config ConfigQemu) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			result.Err = config.CreateVm(result.VmRef, c)
+		}(&results[ii], configs[ii])
+	}
+	wg.Wait()
+
+	failed := false
+	for _, result := range results {
+		failed = failed || result.Err != nil
+	}
+	if failed && rollbackOnFailure {
+		for ii := range results {
+			if results[ii].Err == nil {
+				_, results[ii].RollbackErr = c.DeleteVm(results[ii].VmRef)
+				results[ii].RolledBack = results[ii].RollbackErr == nil
+			}
+		}
+	}
+	return
+}
+
+// ErrVmConfigs - configs GetVmConfigs could not read, by vmid.
+type ErrVmConfigs map[int]error
+
+func (e ErrVmConfigs) Error() string {
+	vmids := make([]int, 0, len(e))
+	for vmid := range e {
+		vmids = append(vmids, vmid)
+	}
+	sort.Ints(vmids)
+	messages := make([]string, 0, len(e))
+	for _, vmid := range vmids {
+		messages = append(messages, fmt.Sprintf("%d: %s", vmid, e[vmid]))
+	}
+	return fmt.Sprintf("cannot read %d VM configs: %s", len(e), strings.Join(messages, "; "))
+}
+
+// GetVmConfigs - Read the configs of vmrs with at most concurrency requests in parallel, keyed by vmid.
+// Node and type of VmRefs without them are resolved with a single cluster listing. Configs that
+// could not be read are left out and reported in an ErrVmConfigs, the others are still returned.
+func (c *Client) GetVmConfigs(vmrs []*VmRef, concurrency int) (configs map[int]map[string]interface{}, err error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	failures := ErrVmConfigs{}
+	var index map[int]map[string]interface{}
+	for _, vmr := range vmrs {
+		if vmr.node != "" && vmr.vmType != "" {
+			continue
+		}
+		if index == nil {
+			index, err = c.GetVmIndex()
+			if err != nil {
+				return nil, err
+			}
+		}
+		vmInfo, exists := index[vmr.vmId]
+		if !exists {
+			failures[vmr.vmId] = &ErrNotFound{fmt.Sprintf("Vm '%d' not found", vmr.vmId)}
+			continue
+		}
+		vmr.node = GetString(vmInfo, "node")
+		vmr.vmType = GetString(vmInfo, "type")
+	}
+
+	configs = map[int]map[string]interface{}{}
+	var mutex sync.Mutex
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, vmr := range vmrs {
+		if _, failed := failures[vmr.vmId]; failed {
+			continue
+		}
+		wg.Add(1)
+		go func(vmr *VmRef) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			vmConfig, err := c.GetVmConfig(vmr)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				failures[vmr.vmId] = err
+				return
+			}
+			configs[vmr.vmId] = vmConfig
+		}(vmr)
+	}
+	wg.Wait()
+	if len(failures) > 0 {
+		return configs, failures
+	}
+	return configs, nil
+}
@@ -0,0 +1,106 @@
+package proxmox
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Optional circuit breaker of the session: after Threshold consecutive failures (transport
+// errors and unavailability statuses, see isUnavailableStatus) to a node, or to the cluster wide endpoints, requests to it fail
+// fast with ErrCircuitOpen. Once Cooldown has elapsed a single probe request is let through,
+// its success closes the circuit and its failure opens it for another Cooldown.
+
+// DefaultCircuitBreakerCooldown - time a circuit stays open when Configuration.CircuitBreakerCooldown is zero.
+const DefaultCircuitBreakerCooldown = 30 * time.Second
+
+// ErrCircuitOpen - request refused without being sent because its target keeps failing.
+type ErrCircuitOpen struct {
+	Target string
+	Until  time.Time
+}
+
+func (e *ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("circuit open for %s until %s", e.Target, e.Until.Format(time.RFC3339))
+}
+
+type circuit struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	mutex     sync.Mutex
+	circuits  map[string]*circuit
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if cooldown <= 0 {
+		cooldown = DefaultCircuitBreakerCooldown
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, circuits: map[string]*circuit{}}
+}
+
+// isUnavailableStatus - does status report an unreachable or overloaded server? Plain 500 is
+// also used by Proxmox for failed operations and is not counted. pveproxy answers 595 and 596
+// when the node a request is forwarded to is unreachable.
+func isUnavailableStatus(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, 595, 596:
+		return true
+	}
+	return false
+}
+
+// breakerTarget - node addressed by an API path, `cluster` for the other endpoints.
+func breakerTarget(path string) string {
+	index := strings.Index(path, "/nodes/")
+	if index < 0 {
+		return "cluster"
+	}
+	node := path[index+len("/nodes/"):]
+	if slash := strings.Index(node, "/"); slash >= 0 {
+		node = node[:slash]
+	}
+	return "node " + node
+}
+
+// allow - may a request be sent to target? While the circuit is open only one probe is allowed after the cooldown.
+func (b *circuitBreaker) allow(target string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	state, isSet := b.circuits[target]
+	if !isSet || state.failures < b.threshold {
+		return nil
+	}
+	if state.probing || time.Now().Before(state.openUntil) {
+		return &ErrCircuitOpen{Target: target, Until: state.openUntil}
+	}
+	state.probing = true
+	return nil
+}
+
+// record - account the outcome of a request to target.
+func (b *circuitBreaker) record(target string, failed bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !failed {
+		delete(b.circuits, target)
+		return
+	}
+	state, isSet := b.circuits[target]
+	if !isSet {
+		state = &circuit{}
+		b.circuits[target] = state
+	}
+	state.failures++
+	state.probing = false
+	if state.failures >= b.threshold {
+		state.openUntil = time.Now().Add(b.cooldown)
+	}
+}
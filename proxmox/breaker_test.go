@@ -0,0 +1,65 @@
+package proxmox
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	const cooldown = 50 * time.Millisecond
+	breaker := newCircuitBreaker(2, cooldown)
+	for _, step := range []struct {
+		name   string
+		target string
+		record *bool
+		wait   bool
+		open   bool
+	}{
+		{name: "closed", target: "node pve1"},
+		{name: "first failure", target: "node pve1", record: &[]bool{true}[0]},
+		{name: "below threshold", target: "node pve1"},
+		{name: "second failure", target: "node pve1", record: &[]bool{true}[0]},
+		{name: "open", target: "node pve1", open: true},
+		{name: "other target", target: "cluster"},
+		{name: "probe after cooldown", target: "node pve1", wait: true},
+		{name: "single probe", target: "node pve1", open: true},
+		{name: "probe fails", target: "node pve1", record: &[]bool{true}[0]},
+		{name: "open again", target: "node pve1", open: true},
+		{name: "second probe", target: "node pve1", wait: true},
+		{name: "probe succeeds", target: "node pve1", record: &[]bool{false}[0]},
+		{name: "closed again", target: "node pve1"},
+	} {
+		if step.wait {
+			time.Sleep(cooldown + 10*time.Millisecond)
+		}
+		if step.record != nil {
+			breaker.record(step.target, *step.record)
+			continue
+		}
+		err := breaker.allow(step.target)
+		var open *ErrCircuitOpen
+		if step.open && !errors.As(err, &open) {
+			t.Fatalf("%s: allow(%q) = %v, want ErrCircuitOpen", step.name, step.target, err)
+		}
+		if !step.open && err != nil {
+			t.Fatalf("%s: allow(%q) = %v, want nil", step.name, step.target, err)
+		}
+	}
+}
+
+func TestBreakerTarget(t *testing.T) {
+	for _, test := range []struct {
+		path string
+		want string
+	}{
+		{"/nodes/pve1/qemu/100/status/current", "node pve1"},
+		{"/nodes/pve2", "node pve2"},
+		{"/cluster/resources", "cluster"},
+		{"/version", "cluster"},
+	} {
+		if got := breakerTarget(test.path); got != test.want {
+			t.Errorf("breakerTarget(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package proxmox
+
+import (
+	"sort"
+)
+
+// BridgeMember - guest network interface attached to a bridge, Tag is the VLAN tag (0 untagged).
+type BridgeMember struct {
+	VmId      int
+	Name      string
+	Type      string
+	Node      string
+	Interface string // net0, net1...
+	Mac       string
+	Tag       int
+}
+
+// GetBridgeMembers - Guest interfaces attached to bridge on node, or on every node when node is
+// empty (bridges are defined per node, usually with the same name on all of them). Guest configs
+// are read one by one, which can take a while on large clusters. Check it before deleting or
+// re-addressing a bridge.
+func (c *Client) GetBridgeMembers(node string, bridge string) (members []BridgeMember, err error) {
+	vms, err := c.GetVmResources()
+	if err != nil {
+		return nil, err
+	}
+	for _, vm := range vms {
+		if node != "" && vm.Node != node {
+			continue
+		}
+		vmr := NewVmRef(int(vm.VmId))
+		vmr.SetNode(vm.Node)
+		vmr.SetVmType(vm.Type)
+		vmConfig, err := c.GetVmConfig(vmr)
+		if err != nil {
+			return nil, err
+		}
+		macs := vmMacs(vmConfig)
+		for key := range vmConfig {
+			if !rxNetConfig.MatchString(key) {
+				continue
+			}
+			confMap := ParseConf(GetString(vmConfig, key), ",", "=")
+			if GetString(confMap, "bridge") != bridge {
+				continue
+			}
+			member := BridgeMember{
+				VmId:      int(vm.VmId),
+				Name:      vm.Name,
+				Type:      vm.Type,
+				Node:      vm.Node,
+				Interface: key,
+				Mac:       macs[key],
+				Tag:       GetIntDefault(confMap, "tag", 0),
+			}
+			members = append(members, member)
+		}
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].VmId != members[j].VmId {
+			return members[i].VmId < members[j].VmId
+		}
+		return members[i].Interface < members[j].Interface
+	})
+	return
+}
@@ -8,6 +8,9 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"net/url"
+	"path"
 	"sync"
 	"regexp"
 	"strconv"
@@ -21,17 +24,85 @@ const TaskTimeout = 300
 // TaskStatusCheckInterval - time between async checks in seconds
 const TaskStatusCheckInterval = 2
 
+// Default bounds of the adaptive task polling: polls start fast and back off to the max interval.
+const (
+	TaskPollMinInterval = 500 * time.Millisecond
+	TaskPollMaxInterval = 10 * time.Second
+)
+
 const HttpTimeout = 30
 
+// TransferTimeout - default timeout in seconds of uploads and downloads.
+const TransferTimeout = 3600
+
+// DefaultUserAgent - User-Agent sent when Configuration.UserAgent is empty.
+const DefaultUserAgent = "proxmox-api-go"
+
 const exitStatusSuccess = "OK"
 
 type Configuration struct {
 	Url   			string
 	Username		string
 	Password		string
+	// API token (`user@realm!name` and its secret), used instead of Username/Password when set.
+	TokenId			string
+	TokenSecret		string
 	TlsInsecure		bool
 	ParallelClone	bool
 	ParallelResize	bool
+	// Allow the root-only `args` QEMU parameter to be sent from ConfigQemu.
+	AllowQemuArgs	bool
+	// Task polling, zero values use TaskTimeout, TaskPollMinInterval and TaskPollMaxInterval.
+	TaskTimeout			time.Duration
+	TaskPollMinInterval	time.Duration
+	TaskPollMaxInterval	time.Duration
+	// Called after each poll of a running task.
+	TaskProgress		func(progress TaskProgress)
+	// Called when WaitForCompletion returns, TaskWebhookUrl is posted the TaskCompletion as JSON
+	// in the background and TaskWebhookError called when that fails.
+	TaskCompleted		func(task TaskCompletion)
+	TaskWebhookUrl		string
+	TaskWebhookError	func(task TaskCompletion, err error)
+	// Limits checked before creating VMs in, or growing disks of VMs in, these pools.
+	PoolQuotas			map[string]PoolQuota
+	// User-Agent of every request, DefaultUserAgent when empty.
+	UserAgent			string
+	// Extra headers sent with every request (e.g. X-Request-ID), to attribute API traffic in server logs.
+	Headers				http.Header
+	// Do not request gzip compressed responses, compression is on by default.
+	DisableCompression	bool
+	// Transport tuning, zero values use the http.DefaultTransport settings (90s idle, 10s TLS handshake).
+	MaxIdleConnsPerHost	int
+	IdleConnTimeout		time.Duration
+	TlsHandshakeTimeout	time.Duration
+	ForceAttemptHTTP2	bool
+	// Request timeouts, zero values use HttpTimeout and TransferTimeout (uploads and downloads).
+	Timeout				time.Duration
+	TransferTimeout		time.Duration
+	// Fail fast after this many consecutive failures to a node, zero disables the circuit breaker.
+	CircuitBreakerThreshold	int
+	CircuitBreakerCooldown	time.Duration
+	// TOTP code completing logins of users with two-factor authentication, TotpCallback is
+	// called at each login when set (e.g. to prompt), TotpCode is used otherwise.
+	TotpCode			string
+	TotpCallback		func() (string, error)
+	// Retries of lock contention errors (`can't lock file ... got timeout`) of reads and tasks,
+	// zero uses DefaultTransientRetries and a negative value disables them. The delay starts at
+	// TransientBackoff (DefaultTransientBackoff when zero) and doubles at each retry.
+	TransientRetries	int
+	TransientBackoff	time.Duration
+	// Guests looked up by id or name and not found are reported missing even when nodes are
+	// offline, instead of failing with ErrNodeOffline.
+	SkipOfflineNodes	bool
+	// Backups BackupGuests runs at once on a node, DefaultBackupNodeConcurrency when zero.
+	BackupNodeConcurrency	int
+}
+
+// TaskProgress - state of a running task reported after each poll.
+type TaskProgress struct {
+	Upid    string
+	Polls   int
+	Elapsed time.Duration
 }
 
 // Client - URL, user and password to specifc Proxmox node
@@ -40,6 +111,8 @@ type Client struct {
 	configuration	*Configuration
 	cloneMutex		sync.Mutex
 	resizeMutex		sync.Mutex
+	versionMutex	sync.Mutex
+	version			*Version
 }
 
 // VmRef - virtual machine ref parts
@@ -68,6 +141,10 @@ func (vmr *VmRef) Node() string {
 	return vmr.node
 }
 
+func (vmr *VmRef) VmType() string {
+	return vmr.vmType
+}
+
 func NewVmRef(vmId int) (vmr *VmRef) {
 	vmr = &VmRef{vmId: vmId, node: "", vmType: ""}
 	return
@@ -86,17 +163,33 @@ func NewClient(configuration *Configuration, autoLogin bool) (client *Client, er
 	return
 }
 
+// WithTimeout - Client sharing the session of c whose requests use timeout instead of the
+// configured one, for slow synchronous calls: `client.WithTimeout(5 * time.Minute).SetVmConfig(...)`.
+// The returned client does not follow later logins of c.
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	return &Client{session: c.session.withTimeout(timeout), configuration: c.configuration}
+}
+
 func (c *Client) Login() (err error) {
+	if c.configuration.TokenId != "" {
+		// Tokens authenticate each request, there is no ticket to get.
+		c.session.SetApiToken(c.configuration.TokenId, c.configuration.TokenSecret)
+		return nil
+	}
 	return c.session.Login(c.configuration.Username, c.configuration.Password)
 }
 
-func (c *Client) GetJsonRetryable(url string, data *map[string]interface{}, tries int) error {
+func (c *Client) GetJsonRetryable(url string, data interface{}, tries int) error {
 	var statErr error
 	for ii := 0; ii < tries; ii++ {
 		_, statErr = c.session.GetJSON(url, nil, nil, data)
 		if statErr == nil {
 			return nil
 		}
+		if IsTransient(statErr) {
+			// Already retried by the session.
+			return statErr
+		}
 		// if statErr != io.ErrUnexpectedEOF { // don't give up on ErrUnexpectedEOF
 		//   return statErr
 		// }
@@ -105,8 +198,40 @@ func (c *Client) GetJsonRetryable(url string, data *map[string]interface{}, trie
 	return statErr
 }
 
-func (c *Client) GetNodeList() (list map[string]interface{}, err error) {
-	err = c.GetJsonRetryable("/nodes", &list, 3)
+// GetNodeList - typed listing of the nodes of the cluster.
+func (c *Client) GetNodeList() (nodes []NodeInfo, err error) {
+	return getApiData[[]NodeInfo](c, "/nodes")
+}
+
+// GetNodes - nodes of the cluster matching filter.
+func (c *Client) GetNodes(filter NodeFilter) (nodes []NodeInfo, err error) {
+	list, err := c.GetNodeList()
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range list {
+		if filter.OnlineOnly && node.Status != "online" {
+			continue
+		}
+		if filter.NameGlob != "" {
+			if match, _ := path.Match(filter.NameGlob, node.Node); !match {
+				continue
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return
+}
+
+// getOnlineNodes - names of the nodes currently online.
+func (c *Client) getOnlineNodes() (nodes []string, err error) {
+	list, err := c.GetNodes(NodeFilter{OnlineOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range list {
+		nodes = append(nodes, node.Node)
+	}
 	return
 }
 
@@ -115,41 +240,153 @@ func (c *Client) GetVmList() (list map[string]interface{}, err error) {
 	return
 }
 
+// CheckVmRef - resolve node and type of vmr when unknown. The cluster listing lags behind
+// creations and migrations and nodes may be briefly unreachable, retryable failures are tried again.
 func (c *Client) CheckVmRef(vmr *VmRef) (err error) {
-	if vmr.node == "" || vmr.vmType == "" {
+	if vmr.node != "" && vmr.vmType != "" {
+		return nil
+	}
+	for tries := 1; ; tries++ {
 		_, err = c.GetVmInfo(vmr)
+		if err == nil || tries == 3 || !IsRetryable(err) {
+			return
+		}
+		time.Sleep(TaskStatusCheckInterval * time.Second)
 	}
-	return
 }
 
 func (c *Client) GetVmInfo(vmr *VmRef) (vmInfo map[string]interface{}, err error) {
-	resp, err := c.GetVmList()
-	vms := resp["data"].([]interface{})
-	for vmii := range vms {
-		vm := vms[vmii].(map[string]interface{})
-		if int(vm["vmid"].(float64)) == vmr.vmId {
-			vmInfo = vm
-			vmr.node = vmInfo["node"].(string)
-			vmr.vmType = vmInfo["type"].(string)
+	if vmr.node != "" {
+		vmInfo, err = c.getVmInfoOnNode(vmr)
+		if err == nil {
 			return
 		}
 	}
-	return nil, errors.New(fmt.Sprintf("Vm '%d' not found", vmr.vmId))
+	index, offlineNodes, err := c.getVmIndex()
+	if err != nil {
+		return nil, err
+	}
+	vmInfo, exists := index[vmr.vmId]
+	if !exists {
+		return nil, c.notFoundError(fmt.Sprintf("Vm '%d' not found", vmr.vmId), offlineNodes)
+	}
+	vmr.node = GetString(vmInfo, "node")
+	vmr.vmType = GetString(vmInfo, "type")
+	return
+}
+
+// getVmInfoOnNode - read the guest status directly from its node, avoiding the cluster wide listing.
+// The guest type is probed when unknown, node and type are added to the result like in /cluster/resources.
+func (c *Client) getVmInfoOnNode(vmr *VmRef) (vmInfo map[string]interface{}, err error) {
+	vmTypes := []string{"qemu", "lxc"}
+	if vmr.vmType != "" {
+		vmTypes = []string{vmr.vmType}
+	}
+	for _, vmType := range vmTypes {
+		url := ApiPath("nodes", vmr.node, vmType, vmr.vmId, "status", "current")
+		vmInfo, err = getApiDataWithParams[map[string]interface{}](c, url, nil)
+		if err != nil {
+			continue
+		}
+		if vmInfo != nil {
+			vmInfo["node"] = vmr.node
+			vmInfo["type"] = vmType
+			vmr.vmType = vmType
+			return vmInfo, nil
+		}
+	}
+	if err == nil {
+		err = &ErrNotFound{fmt.Sprintf("Vm '%d' not found on node '%s'", vmr.vmId, vmr.node)}
+	}
+	return nil, err
+}
+
+// TryGetVmOnNode - Look up a guest on the node it is expected on, probing the qemu then lxc status
+// endpoints without the cluster wide listing. Returns its VmRef with node and type set and its current
+// status, ErrNotFound when the node has no such guest (it may have been migrated).
+func (c *Client) TryGetVmOnNode(node string, vmid int) (vmr *VmRef, vmState map[string]interface{}, err error) {
+	vmr = NewVmRef(vmid)
+	vmr.SetNode(node)
+	vmState, err = c.getVmInfoOnNode(vmr)
+	if err != nil {
+		if IsNotFound(err) {
+			err = &ErrNotFound{fmt.Sprintf("Vm '%d' not found on node '%s'", vmid, node)}
+		}
+		return nil, nil, err
+	}
+	return vmr, vmState, nil
+}
+
+// GetVmIndex - cluster resources of all guests keyed by vmid, built from a single listing.
+func (c *Client) GetVmIndex() (index map[int]map[string]interface{}, err error) {
+	index, _, err = c.getVmIndex()
+	return
+}
+
+// getVmIndex - GetVmIndex with the nodes that are offline, whose guests may be missing.
+func (c *Client) getVmIndex() (index map[int]map[string]interface{}, offlineNodes []string, err error) {
+	resources, err := getApiData[[]map[string]interface{}](c, "/cluster/resources")
+	if err != nil {
+		return nil, nil, err
+	}
+	if resources == nil {
+		return nil, nil, errors.New("Vm LIST not readable")
+	}
+	index = map[int]map[string]interface{}{}
+	for _, resource := range resources {
+		switch GetString(resource, "type") {
+		case "qemu", "lxc":
+			if vmid, err := GetInt(resource, "vmid"); err == nil {
+				index[vmid] = resource
+			}
+		case "node":
+			if GetString(resource, "status") != "online" {
+				offlineNodes = append(offlineNodes, GetString(resource, "node"))
+			}
+		}
+	}
+	return
+}
+
+// ErrNodeOffline - a guest was not found but nodes are offline, it may be on one of them.
+// It wraps the ErrNotFound, callers telling both apart check for ErrNodeOffline first.
+type ErrNodeOffline struct {
+	Nodes []string
+	Err   error
+}
+
+func (e *ErrNodeOffline) Error() string {
+	return fmt.Sprintf("%s, nodes offline: %s", e.Err, strings.Join(e.Nodes, ", "))
+}
+
+func (e *ErrNodeOffline) Unwrap() error {
+	return e.Err
+}
+
+// notFoundError - error for a guest missing from the cluster listing, ErrNodeOffline when the
+// listing is incomplete unless SkipOfflineNodes is set.
+func (c *Client) notFoundError(message string, offlineNodes []string) error {
+	err := &ErrNotFound{message}
+	if len(offlineNodes) == 0 || c.configuration.SkipOfflineNodes {
+		return err
+	}
+	return &ErrNodeOffline{Nodes: offlineNodes, Err: err}
 }
 
 func (c *Client) GetVmRefByName(vmName string) (vmr *VmRef, err error) {
-	resp, err := c.GetVmList()
-	vms := resp["data"].([]interface{})
-	for vmii := range vms {
-		vm := vms[vmii].(map[string]interface{})
-		if vm["name"] != nil && vm["name"].(string) == vmName {
-			vmr = NewVmRef(int(vm["vmid"].(float64)))
-			vmr.node = vm["node"].(string)
-			vmr.vmType = vm["type"].(string)
+	list, err := c.GetVmResourceList()
+	if err != nil {
+		return nil, err
+	}
+	for _, vm := range list.Vms {
+		if vm.Name == vmName {
+			vmr = NewVmRef(int(vm.VmId))
+			vmr.node = vm.Node
+			vmr.vmType = vm.Type
 			return
 		}
 	}
-	return nil, errors.New(fmt.Sprintf("Vm '%s' not found", vmName))
+	return nil, c.notFoundError(fmt.Sprintf("Vm '%s' not found", vmName), list.UnreachableNodes)
 }
 
 func (c *Client) GetVmState(vmr *VmRef) (vmState map[string]interface{}, err error) {
@@ -157,16 +394,10 @@ func (c *Client) GetVmState(vmr *VmRef) (vmState map[string]interface{}, err err
 	if err != nil {
 		return nil, err
 	}
-	var data map[string]interface{}
-	url := fmt.Sprintf("/nodes/%s/%s/%d/status/current", vmr.node, vmr.vmType, vmr.vmId)
-	err = c.GetJsonRetryable(url, &data, 3)
-	if err != nil {
-		return nil, err
-	}
-	if data["data"] == nil {
+	vmState, err = getApiData[map[string]interface{}](c, ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "status", "current"))
+	if err == nil && vmState == nil {
 		return nil, errors.New("Vm STATE not readable")
 	}
-	vmState = data["data"].(map[string]interface{})
 	return
 }
 
@@ -175,16 +406,10 @@ func (c *Client) GetVmConfig(vmr *VmRef) (vmConfig map[string]interface{}, err e
 	if err != nil {
 		return nil, err
 	}
-	var data map[string]interface{}
-	url := fmt.Sprintf("/nodes/%s/%s/%d/config", vmr.node, vmr.vmType, vmr.vmId)
-	err = c.GetJsonRetryable(url, &data, 3)
-	if err != nil {
-		return nil, err
-	}
-	if data["data"] == nil {
+	vmConfig, err = getApiData[map[string]interface{}](c, ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "config"))
+	if err == nil && vmConfig == nil {
 		return nil, errors.New("Vm CONFIG not readable")
 	}
-	vmConfig = data["data"].(map[string]interface{})
 	return
 }
 
@@ -194,12 +419,23 @@ func (c *Client) MonitorCmd(vmr *VmRef, command string) (monitorRes map[string]i
 		return nil, err
 	}
 	reqbody := ParamsToBody(map[string]interface{}{"command": command})
-	url := fmt.Sprintf("/nodes/%s/%s/%d/monitor", vmr.node, vmr.vmType, vmr.vmId)
+	url := ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "monitor")
 	resp, err := c.session.Post(url, nil, nil, &reqbody)
 	monitorRes = ResponseJSON(resp)
 	return
 }
 
+// ErrUnexpectedResponse - the API answered with a payload of unexpected shape, the raw payload is attached.
+type ErrUnexpectedResponse struct {
+	Message string
+	Payload interface{}
+}
+
+func (e *ErrUnexpectedResponse) Error() string {
+	payload, _ := json.Marshal(e.Payload)
+	return fmt.Sprintf("%s: %s", e.Message, payload)
+}
+
 // WaitForCompletion - poll the API for task completion
 func (c *Client) WaitForCompletion(taskResponse map[string]interface{}) (waitExitStatus string, err error) {
 	if taskResponse["errors"] != nil {
@@ -209,16 +445,28 @@ func (c *Client) WaitForCompletion(taskResponse map[string]interface{}) (waitExi
 	if taskResponse["data"] == nil {
 		return "", nil
 	}
-	waited := 0
-	taskUpid := taskResponse["data"].(string)
-	for waited < TaskTimeout {
+	taskUpid, ok := taskResponse["data"].(string)
+	if !ok {
+		return "", &ErrUnexpectedResponse{"task response is not an UPID", taskResponse}
+	}
+	timeout, interval, maxInterval := c.taskPolling()
+	start := time.Now()
+	defer func() {
+		c.notifyTaskCompletion(newTaskCompletion(taskUpid, start, waitExitStatus, err))
+	}()
+	for polls := 1; time.Since(start) < timeout; polls++ {
 		exitStatus, statErr := c.GetTaskExitstatus(taskUpid)
+		if statErr != nil && exitStatus != nil {
+			// The task failed, its exit status is returned with the error.
+			waitExitStatus, _ = exitStatus.(string)
+			return waitExitStatus, statErr
+		}
 		if statErr != nil {
-	        if apiError, ok := statErr.(*ApiError); ok && apiError.Code == ApiErrorTooManyRedirections {
+			apiError, isApiError := statErr.(*ApiError)
+			if isApiError && apiError.Code == ApiErrorTooManyRedirections {
 				log.Println("Facing an error 599 on API, retrying ...")
 				exitStatus = nil
-	        }
-			if statErr != io.ErrUnexpectedEOF { // don't give up on ErrUnexpectedEOF
+			} else if statErr != io.ErrUnexpectedEOF { // don't give up on ErrUnexpectedEOF
 				return "", statErr
 			}
 		}
@@ -226,25 +474,65 @@ func (c *Client) WaitForCompletion(taskResponse map[string]interface{}) (waitExi
 			waitExitStatus = exitStatus.(string)
 			return
 		}
-		time.Sleep(TaskStatusCheckInterval * time.Second)
-		waited = waited + TaskStatusCheckInterval
+		if c.configuration.TaskProgress != nil {
+			c.configuration.TaskProgress(TaskProgress{Upid: taskUpid, Polls: polls, Elapsed: time.Since(start)})
+		}
+		time.Sleep(interval)
+		interval = interval * 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
 	}
-	return "", errors.New("Wait timeout for:" + taskUpid)
+	return "", &ErrTaskTimeout{Upid: taskUpid}
+}
+
+// taskPolling - task timeout and polling interval bounds from the configuration or the defaults.
+func (c *Client) taskPolling() (timeout time.Duration, minInterval time.Duration, maxInterval time.Duration) {
+	timeout = c.configuration.TaskTimeout
+	if timeout <= 0 {
+		timeout = TaskTimeout * time.Second
+	}
+	minInterval = c.configuration.TaskPollMinInterval
+	if minInterval <= 0 {
+		minInterval = TaskPollMinInterval
+	}
+	maxInterval = c.configuration.TaskPollMaxInterval
+	if maxInterval <= 0 {
+		maxInterval = TaskPollMaxInterval
+	}
+	if maxInterval < minInterval {
+		maxInterval = minInterval
+	}
+	return
 }
 
 var rxTaskNode = regexp.MustCompile("UPID:(.*?):")
 
+// GetTaskExitstatus - exit status of a task, nil while it is running.
+// A non nil exit status is always a string.
 func (c *Client) GetTaskExitstatus(taskUpid string) (exitStatus interface{}, err error) {
-	node := rxTaskNode.FindStringSubmatch(taskUpid)[1]
-	url := fmt.Sprintf("/nodes/%s/tasks/%s/status", node, taskUpid)
-	var data map[string]interface{}
-	_, err = c.session.GetJSON(url, nil, nil, &data)
+	nodeMatch := rxTaskNode.FindStringSubmatch(taskUpid)
+	if nodeMatch == nil {
+		return nil, &ErrUnexpectedResponse{"malformed task UPID", taskUpid}
+	}
+	node := nodeMatch[1]
+	taskStatus, err := getApiDataWithParams[map[string]interface{}](c, ApiPath("nodes", node, "tasks", taskUpid, "status"), nil)
 	if err != nil {
 		return nil, err
 	}
-	exitStatus = data["data"].(map[string]interface{})["exitstatus"]
-	if exitStatus != nil && exitStatus != exitStatusSuccess {
-		err = errors.New(exitStatus.(string))
+	if taskStatus == nil {
+		return nil, &ErrUnexpectedResponse{"task status not readable", taskUpid}
+	}
+	exitStatus = taskStatus["exitstatus"]
+	if exitStatus == nil {
+		return nil, nil
+	}
+	exitStatusString, ok := exitStatus.(string)
+	if !ok {
+		return nil, &ErrUnexpectedResponse{"task exit status is not a string", taskStatus}
+	}
+	if exitStatusString != exitStatusSuccess {
+		err = checkVmLocked(errors.New(exitStatusString))
 	}
 	return
 }
@@ -255,18 +543,13 @@ func (c *Client) StatusChangeVm(vmr *VmRef, setStatus string) (exitStatus string
 		return "", err
 	}
 
-	url := fmt.Sprintf("/nodes/%s/%s/%d/status/%s", vmr.node, vmr.vmType, vmr.vmId, setStatus)
-	var taskResponse map[string]interface{}
-	for i := 0; i < 3; i++ {
+	url := ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "status", setStatus)
+	// Status changes are not idempotent, they are only sent again when the request did not reach
+	// the server or was refused before being processed. Once a task is started it is only waited for.
+	return c.retryTransientTask(func() (taskResponse map[string]interface{}, err error) {
 		_, err = c.session.PostJSON(url, nil, nil, nil, &taskResponse)
-		exitStatus, err = c.WaitForCompletion(taskResponse)
-		if exitStatus == "" {
-			time.Sleep(TaskStatusCheckInterval * time.Second)
-		} else {
-			return
-		}
-	}
-	return
+		return
+	})
 }
 
 func (c *Client) StartVm(vmr *VmRef) (exitStatus string, err error) {
@@ -285,6 +568,22 @@ func (c *Client) ResetVm(vmr *VmRef) (exitStatus string, err error) {
 	return c.StatusChangeVm(vmr, "reset")
 }
 
+// RebootVm - use the reboot endpoint when available, shutdown then start on older PVE.
+func (c *Client) RebootVm(vmr *VmRef) (exitStatus string, err error) {
+	caps, err := c.GetCapabilities()
+	if err != nil {
+		return "", err
+	}
+	if caps.RebootEndpoint {
+		return c.StatusChangeVm(vmr, "reboot")
+	}
+	exitStatus, err = c.ShutdownVm(vmr)
+	if err != nil {
+		return
+	}
+	return c.StartVm(vmr)
+}
+
 func (c *Client) SuspendVm(vmr *VmRef) (exitStatus string, err error) {
 	return c.StatusChangeVm(vmr, "suspend")
 }
@@ -298,35 +597,124 @@ func (c *Client) DeleteVm(vmr *VmRef) (exitStatus string, err error) {
 	if err != nil {
 		return "", err
 	}
-	url := fmt.Sprintf("/nodes/%s/%s/%d", vmr.node, vmr.vmType, vmr.vmId)
+	url := ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId)
 	var taskResponse map[string]interface{}
 	_, err = c.session.RequestJSON("DELETE", url, nil, nil, nil, &taskResponse)
 	exitStatus, err = c.WaitForCompletion(taskResponse)
 	return
 }
 
+// QemuCreateOptions - typed parameters of a VM creation, validated before being sent.
+// Params holds any other creation parameter (disks, networks...) in raw API form.
+// Disks are allocated by Proxmox (`storage:size` syntax) unless PreallocateDisks is set,
+// in which case disks with an explicit file name are created on storage before the VM.
+type QemuCreateOptions struct {
+	VmId             int
+	Name             string
+	Memory           int
+	Cores            int
+	Sockets          int
+	Params           map[string]interface{}
+	PreallocateDisks bool
+}
+
+// Bounds accepted by Proxmox for VM creation.
+const (
+	VmIdMin     = 100
+	VmIdMax     = 999999999
+	MinMemoryMB = 16
+	MaxMemoryMB = 4194304
+)
+
+// Proxmox VM names must be valid DNS names.
+var rxVmName = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9\-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9\-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// Validate - check the options client side, before calling the API.
+func (o QemuCreateOptions) Validate() error {
+	if o.VmId < VmIdMin || o.VmId > VmIdMax {
+		return fmt.Errorf("vmid %d out of range %d-%d", o.VmId, VmIdMin, VmIdMax)
+	}
+	if o.Name != "" && !rxVmName.MatchString(o.Name) {
+		return fmt.Errorf("VM name '%s' is not a valid DNS name", o.Name)
+	}
+	if o.Memory != 0 && (o.Memory < MinMemoryMB || o.Memory > MaxMemoryMB) {
+		return fmt.Errorf("memory %dMB out of range %d-%d", o.Memory, MinMemoryMB, MaxMemoryMB)
+	}
+	if o.Cores < 0 || o.Sockets < 0 {
+		return errors.New("cores and sockets must be positive")
+	}
+	return nil
+}
+
+// params - API parameters of the options, typed fields take precedence over Params.
+func (o QemuCreateOptions) params() map[string]interface{} {
+	vmParams := map[string]interface{}{}
+	for k, v := range o.Params {
+		vmParams[k] = v
+	}
+	vmParams["vmid"] = o.VmId
+	if o.Name != "" {
+		vmParams["name"] = o.Name
+	}
+	if o.Memory > 0 {
+		vmParams["memory"] = o.Memory
+	}
+	if o.Cores > 0 {
+		vmParams["cores"] = o.Cores
+	}
+	if o.Sockets > 0 {
+		vmParams["sockets"] = o.Sockets
+	}
+	return vmParams
+}
+
+// CreateQemuVmWithOptions - validate the options then create the VM on node.
+func (c *Client) CreateQemuVmWithOptions(node string, options QemuCreateOptions) (exitStatus string, err error) {
+	err = options.Validate()
+	if err != nil {
+		return "", err
+	}
+	return c.createQemuVm(node, options.params(), options.PreallocateDisks)
+}
+
+// CreateQemuVm - create a VM from raw API parameters, prefer CreateQemuVmWithOptions.
+// Disks are allocated by Proxmox, disks with an explicit file name need
+// CreateQemuVmWithOptions with PreallocateDisks to be created on storage first.
 func (c *Client) CreateQemuVm(node string, vmParams map[string]interface{}) (exitStatus string, err error) {
+	return c.createQemuVm(node, vmParams, false)
+}
+
+// Any failure removes what was created (disks, VM), ErrCreationLeftovers reports what could not be.
+func (c *Client) createQemuVm(node string, vmParams map[string]interface{}, preallocateDisks bool) (exitStatus string, err error) {
+	creation := &vmCreation{client: c, node: node, vmid: GetIntDefault(vmParams, "vmid", 0)}
 
 	// Create VM disks first to ensure disks names.
-	createdDisks, createdDisksErr := c.createVMDisks(node, vmParams)
-	if createdDisksErr != nil {
-		return "", createdDisksErr
-
-		// Then create the VM itself.
-	} else if err == nil {
-		reqbody := ParamsToBody(vmParams)
-		url := fmt.Sprintf("/nodes/%s/qemu", node)
-		resp, err := c.session.Post(url, nil, nil, &reqbody)
-		if err == nil {
-			taskResponse := ResponseJSON(resp)
-			exitStatus, err = c.WaitForCompletion(taskResponse)
-			// Delete VM disks if the VM didn't create.
-			if exitStatus != "OK" {
-				deleteDisksErr := c.DeleteVMDisks(node, createdDisks)
-				if deleteDisksErr != nil {
-					return "", deleteDisksErr
-				}
-			}
+	if preallocateDisks {
+		creation.disks, err = c.createVMDisks(node, vmParams)
+		if err != nil {
+			return "", creation.fail(err)
+		}
+	}
+
+	// Then create the VM itself.
+	reqbody := ParamsToBody(vmParams)
+	url := ApiPath("nodes", node, "qemu")
+	resp, err := c.session.Post(url, nil, nil, &reqbody)
+	if err != nil {
+		creation.taskRunning = requestMayHaveApplied(err)
+		return "", creation.fail(err)
+	}
+	taskResponse := ResponseJSON(resp)
+	_, creation.started = taskResponse["data"].(string)
+	exitStatus, err = c.WaitForCompletion(taskResponse)
+	var timeoutErr *ErrTaskTimeout
+	creation.taskRunning = errors.As(err, &timeoutErr)
+	if err != nil {
+		return "", creation.fail(err)
+	}
+	if exitStatus != exitStatusSuccess {
+		if cleanupErr := creation.rollback(errors.New(exitStatus)); cleanupErr != nil {
+			return exitStatus, cleanupErr
 		}
 	}
 	return
@@ -334,15 +722,45 @@ func (c *Client) CreateQemuVm(node string, vmParams map[string]interface{}) (exi
 
 func (c *Client) CloneQemuVm(vmr *VmRef, vmParams map[string]interface{}) (exitStatus string, err error) {
 	reqbody := ParamsToBody(vmParams)
-	url := fmt.Sprintf("/nodes/%s/qemu/%d/clone", vmr.node, vmr.vmId)
+	url := ApiPath("nodes", vmr.node, "qemu", vmr.vmId, "clone")
 	if !c.configuration.ParallelClone {
 		c.cloneMutex.Lock()
 		defer c.cloneMutex.Unlock()
 	}
-	resp, err := c.session.Post(url, nil, nil, &reqbody)
+	return c.retryTransientTask(func() (taskResponse map[string]interface{}, err error) {
+		resp, err := c.session.Post(url, nil, nil, &reqbody)
+		if err != nil {
+			return nil, err
+		}
+		return ResponseJSON(resp), nil
+	})
+}
+
+// MigrateVm - migrate a guest to targetNode, online (live) migration for running VMs.
+func (c *Client) MigrateVm(vmr *VmRef, targetNode string, online bool) (exitStatus string, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return "", err
+	}
+	params := map[string]interface{}{"target": targetNode}
+	if online {
+		if vmr.vmType == "lxc" {
+			params["restart"] = true
+		} else {
+			params["online"] = true
+		}
+	}
+	reqbody := ParamsToBody(params)
+	url := ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "migrate")
+	exitStatus, err = c.retryTransientTask(func() (taskResponse map[string]interface{}, err error) {
+		resp, err := c.session.Post(url, nil, nil, &reqbody)
+		if err != nil {
+			return nil, err
+		}
+		return ResponseJSON(resp), nil
+	})
 	if err == nil {
-		taskResponse := ResponseJSON(resp)
-		exitStatus, err = c.WaitForCompletion(taskResponse)
+		vmr.node = targetNode
 	}
 	return
 }
@@ -352,7 +770,7 @@ func (c *Client) RollbackQemuVm(vmr *VmRef, snapshot string) (exitStatus string,
 	if err != nil {
 		return "", err
 	}
-	url := fmt.Sprintf("/nodes/%s/%s/%d/snapshot/%s/rollback", vmr.node, vmr.vmType, vmr.vmId, snapshot)
+	url := ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "snapshot", snapshot, "rollback")
 	var taskResponse map[string]interface{}
 	_, err = c.session.PostJSON(url, nil, nil, nil, &taskResponse)
 	exitStatus, err = c.WaitForCompletion(taskResponse)
@@ -362,15 +780,39 @@ func (c *Client) RollbackQemuVm(vmr *VmRef, snapshot string) (exitStatus string,
 // SetVmConfig - send config options
 func (c *Client) SetVmConfig(vmr *VmRef, vmParams map[string]interface{}) (exitStatus interface{}, err error) {
 	reqbody := ParamsToBody(vmParams)
-	url := fmt.Sprintf("/nodes/%s/%s/%d/config", vmr.node, vmr.vmType, vmr.vmId)
-	resp, err := c.session.Post(url, nil, nil, &reqbody)
+	url := ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "config")
+	return c.retryTransientTask(func() (taskResponse map[string]interface{}, err error) {
+		resp, err := c.session.Post(url, nil, nil, &reqbody)
+		if err != nil {
+			return nil, err
+		}
+		return ResponseJSON(resp), nil
+	})
+}
+
+// SetLxcConfig - send container config options, containers are updated synchronously with PUT.
+func (c *Client) SetLxcConfig(vmr *VmRef, vmParams map[string]interface{}) (exitStatus interface{}, err error) {
+	reqbody := ParamsToBody(vmParams)
+	url := ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "config")
+	resp, err := c.session.Put(url, nil, nil, &reqbody)
 	if err == nil {
-		taskResponse := ResponseJSON(resp)
-		exitStatus, err = c.WaitForCompletion(taskResponse)
+		exitStatus = ResponseJSON(resp)["data"]
 	}
 	return
 }
 
+// setGuestConfig - update a VM or container config, each guest type uses its own method.
+func (c *Client) setGuestConfig(vmr *VmRef, params map[string]interface{}) (exitStatus interface{}, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return nil, err
+	}
+	if vmr.vmType == "lxc" {
+		return c.SetLxcConfig(vmr, params)
+	}
+	return c.SetVmConfig(vmr, params)
+}
+
 func (c *Client) ResizeQemuDisk(vmr *VmRef, disk string, moreSizeGB int) (exitStatus interface{}, err error) {
 	// PUT
 	//disk:virtio0
@@ -379,13 +821,38 @@ func (c *Client) ResizeQemuDisk(vmr *VmRef, disk string, moreSizeGB int) (exitSt
 		disk = "virtio0"
 	}
 	size := fmt.Sprintf("+%dG", moreSizeGB)
+	err = c.checkResizeQuota(vmr, disk, "", float64(moreSizeGB))
+	if err != nil {
+		return nil, err
+	}
+	reqbody := ParamsToBody(map[string]interface{}{"disk": disk, "size": size})
+	if !c.configuration.ParallelResize {
+		c.resizeMutex.Lock()
+		defer c.resizeMutex.Unlock()
+	}
+
+	url := ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "resize")
+	resp, err := c.session.Put(url, nil, nil, &reqbody)
+	if err == nil {
+		taskResponse := ResponseJSON(resp)
+		exitStatus, err = c.WaitForCompletion(taskResponse)
+	}
+	return
+}
+
+// ResizeQemuDiskTo - grow a disk to an absolute size like `20G`, Proxmox cannot shrink disks.
+func (c *Client) ResizeQemuDiskTo(vmr *VmRef, disk string, size string) (exitStatus interface{}, err error) {
+	err = c.checkResizeQuota(vmr, disk, size, 0)
+	if err != nil {
+		return nil, err
+	}
 	reqbody := ParamsToBody(map[string]interface{}{"disk": disk, "size": size})
 	if !c.configuration.ParallelResize {
 		c.resizeMutex.Lock()
 		defer c.resizeMutex.Unlock()
 	}
 
-	url := fmt.Sprintf("/nodes/%s/%s/%d/resize", vmr.node, vmr.vmType, vmr.vmId)
+	url := ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "resize")
 	resp, err := c.session.Put(url, nil, nil, &reqbody)
 	if err == nil {
 		taskResponse := ResponseJSON(resp)
@@ -394,26 +861,53 @@ func (c *Client) ResizeQemuDisk(vmr *VmRef, disk string, moreSizeGB int) (exitSt
 	return
 }
 
+// checkResizeQuota - check the pool quota of the VM before growing disk by moreSizeGB, or to newSize when set.
+func (c *Client) checkResizeQuota(vmr *VmRef, disk string, newSize string, moreSizeGB float64) error {
+	if len(c.configuration.PoolQuotas) == 0 {
+		return nil
+	}
+	err := c.CheckVmRef(vmr)
+	if err != nil {
+		return err
+	}
+	// The pool is only listed by the cluster resources, the node status of the VM leaves it out.
+	index, offlineNodes, err := c.getVmIndex()
+	if err != nil {
+		return err
+	}
+	vmInfo, exists := index[vmr.vmId]
+	if !exists {
+		return c.notFoundError(fmt.Sprintf("Vm '%d' not found", vmr.vmId), offlineNodes)
+	}
+	if newSize != "" {
+		vmConfig, err := c.GetVmConfig(vmr)
+		if err != nil {
+			return err
+		}
+		diskConfMap := ParseConf(GetString(vmConfig, disk), ",", "=")
+		currentGB, _ := diskSizeGB(diskConfMap["size"])
+		newGB, _ := diskSizeGB(newSize)
+		moreSizeGB = newGB - currentGB
+	}
+	return c.CheckPoolQuota(GetString(vmInfo, "pool"), QuotaRequest{DiskGB: moreSizeGB})
+}
+
 // GetNextID - Get next free VMID
 func (c *Client) GetNextID(currentID int) (nextID int, err error) {
-	var url string
+	params := url.Values{}
 	if currentID > 0 {
-		url = fmt.Sprintf("/cluster/nextid?vmid=%d", currentID)
-	} else {
-		url = "/cluster/nextid"
+		params.Set("vmid", strconv.Itoa(currentID))
 	}
 
-	var data map[string]interface{}
-	_, err = c.session.GetJSON(url, nil, nil, &data)
-
-	if err != nil || data["errors"] != nil {
+	id, err := getApiDataWithParams[FlexInt](c, "/cluster/nextid", params)
+	if err != nil {
 		if currentID != 0 {
 			return c.GetNextID(0)
 		} else {
 			return -1, errors.New("error using /cluster/nextid")
 		}
 	}
-	return strconv.Atoi(data["data"].(string))
+	return int(id), nil
 }
 
 // CreateVMDisk - Create single disk for VM on host node.
@@ -425,7 +919,7 @@ func (c *Client) CreateVMDisk(
 ) error {
 
 	reqbody := ParamsToBody(diskParams)
-	url := fmt.Sprintf("/nodes/%s/storage/%s/content", nodeName, storageName)
+	url := ApiPath("nodes", nodeName, "storage", storageName, "content")
 	resp, err := c.session.Post(url, nil, nil, &reqbody)
 	if err == nil {
 		taskResponse := ResponseJSON(resp)
@@ -450,6 +944,10 @@ func (c *Client) createVMDisks(
 		rxStorageModels := `(ide|sata|scsi|virtio)\d+`
 		if matched, _ := regexp.MatchString(rxStorageModels, deviceName); matched {
 			deviceConfMap := ParseConf(deviceConf.(string), ",", "=")
+			// Disks imported from an existing image are allocated by Proxmox itself.
+			if _, isImported := deviceConfMap["import-from"]; isImported {
+				continue
+			}
 			// This if condition to differentiate between `disk` and `cdrom`.
 			if media, containsFile := deviceConfMap["media"]; containsFile && media == "disk" {
 				fullDiskName := deviceConfMap["file"].(string)
@@ -480,9 +978,7 @@ func (c *Client) DeleteVMDisks(
 	disks []string,
 ) error {
 	for _, fullDiskName := range disks {
-		storageName, volumeName := getStorageAndVolumeName(fullDiskName, ":")
-		url := fmt.Sprintf("/nodes/%s/storage/%s/content/%s", node, storageName, volumeName)
-		_, err := c.session.Post(url, nil, nil, nil)
+		_, err := c.DeleteVolume(node, fullDiskName)
 		if err != nil {
 			return err
 		}
@@ -491,13 +987,36 @@ func (c *Client) DeleteVMDisks(
 	return nil
 }
 
+// ImportDisk - Attach a new disk to an existing VM, filled from an image already on storage (qcow2, raw, vmdk).
+// This is the API equivalent of `qm importdisk`, source is a volid (`local:import/image.qcow2`) or an absolute path.
+func (c *Client) ImportDisk(vmr *VmRef, disk string, storage string, source string) (exitStatus interface{}, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return nil, err
+	}
+	caps, err := c.GetCapabilities()
+	if err != nil {
+		return nil, err
+	}
+	if !caps.ImportFrom {
+		return nil, errors.New("import-from requires Proxmox VE 7.2 or later")
+	}
+	configParams := map[string]interface{}{
+		disk: fmt.Sprintf("%s:0,import-from=%s", storage, source),
+	}
+	return c.SetVmConfig(vmr, configParams)
+}
+
 // getStorageAndVolumeName - Extract disk storage and disk volume, since disk name is saved
 // in Proxmox with its storage.
 func getStorageAndVolumeName(
 	fullDiskName string,
 	separator string,
 ) (storageName string, diskName string) {
-	storageAndVolumeName := strings.Split(fullDiskName, separator)
+	storageAndVolumeName := strings.SplitN(fullDiskName, separator, 2)
+	if len(storageAndVolumeName) < 2 {
+		return "", storageAndVolumeName[0]
+	}
 	storageName, volumeName := storageAndVolumeName[0], storageAndVolumeName[1]
 	return storageName, volumeName
 }
@@ -0,0 +1,192 @@
+package proxmox
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CloneDiskTarget - where a disk of a clone must end up, an empty Format keeps the storage default.
+type CloneDiskTarget struct {
+	Storage string
+	Format  string
+}
+
+// MoveQemuDisk - Move a disk (virtio0, scsi1...) of a VM to storage, optionally converting it to
+// format (raw, qcow2, vmdk). The source volume is removed when deleteSource is set, otherwise it is
+// kept as an unusedN disk.
+func (c *Client) MoveQemuDisk(vmr *VmRef, disk string, storage string, format string, deleteSource bool) (exitStatus string, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return "", err
+	}
+	params := map[string]interface{}{
+		"disk":    disk,
+		"storage": storage,
+	}
+	if format != "" {
+		params["format"] = format
+	}
+	if deleteSource {
+		params["delete"] = 1
+	}
+	reqbody := ParamsToBody(params)
+	url := ApiPath("nodes", vmr.node, "qemu", vmr.vmId, "move_disk")
+	resp, err := c.session.Post(url, nil, nil, &reqbody)
+	if err == nil {
+		taskResponse := ResponseJSON(resp)
+		exitStatus, err = c.WaitForCompletion(taskResponse)
+	}
+	return
+}
+
+// diskStorageAndFormat - storage and format of a disk config value like `local:100/vm-100-disk-0.qcow2,size=8G`.
+func diskStorageAndFormat(diskConf string) (storage string, format string) {
+	volume := strings.SplitN(diskConf, ",", 2)[0]
+	storage, volumeName := getStorageAndVolumeName(volume, ":")
+	format, _ = ParseConf(diskConf, ",", "=")["format"].(string)
+	if format == "" {
+		format = strings.TrimPrefix(path.Ext(volumeName), ".")
+	}
+	if format == "" {
+		// Block storages only hold raw volumes.
+		format = "raw"
+	}
+	return
+}
+
+// CloneQemuVmToDisks - Full clone of sourceVmr to vmr, then move each disk listed in targets
+// (by name: virtio0, scsi1...) to its own storage and format, e.g. the system disk to local-lvm
+// and a data disk to ceph. The clone itself uses the `storage` and `format` of params, disks
+// already matching their target are not moved.
+func (c *Client) CloneQemuVmToDisks(sourceVmr *VmRef, vmr *VmRef, params map[string]interface{}, targets map[string]CloneDiskTarget) (err error) {
+	cloneParams := map[string]interface{}{}
+	for key, value := range params {
+		cloneParams[key] = value
+	}
+	cloneParams["newid"] = vmr.vmId
+	cloneParams["full"] = 1
+	if vmr.node != "" {
+		cloneParams["target"] = vmr.node
+	}
+	exitStatus, err := c.CloneQemuVm(sourceVmr, cloneParams)
+	if err != nil {
+		return err
+	}
+	if exitStatus != exitStatusSuccess {
+		return fmt.Errorf("clone of VM %d failed: %s", sourceVmr.vmId, exitStatus)
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	vmr.SetVmType("qemu")
+	if vmr.node == "" {
+		vmr.SetNode(sourceVmr.node)
+	}
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return err
+	}
+	// Move disks in a stable order so failures are reproducible.
+	disks := make([]string, 0, len(targets))
+	for disk := range targets {
+		disks = append(disks, disk)
+	}
+	sort.Strings(disks)
+	for _, disk := range disks {
+		target := targets[disk]
+		diskConf, ok := vmConfig[disk].(string)
+		if !ok {
+			return fmt.Errorf("clone %d has no disk %s", vmr.vmId, disk)
+		}
+		storage, format := diskStorageAndFormat(diskConf)
+		if storage == target.Storage && (target.Format == "" || target.Format == format) {
+			continue
+		}
+		targetStorage := target.Storage
+		if targetStorage == "" {
+			targetStorage = storage
+		}
+		exitStatus, err = c.MoveQemuDisk(vmr, disk, targetStorage, target.Format, true)
+		if err != nil {
+			return err
+		}
+		if exitStatus != exitStatusSuccess {
+			return fmt.Errorf("move of disk %s of VM %d to %s failed: %s", disk, vmr.vmId, targetStorage, exitStatus)
+		}
+	}
+	return nil
+}
+
+// Owner of a volume, from its name `vm-100-disk-0` or `base-100-disk-0`.
+var rxVolumeOwner = regexp.MustCompile(`(?:^|/)(?:vm|base)-(\d+)-`)
+
+// ReassignVolume - Detach the volume volid from the VM owning it and attach it to VM targetVmid,
+// renaming the volume for its new owner without copying data (PVE 7.1+). Both VMs must be on the
+// same node. targetDisk is the option of the target VM to attach it to (scsi1, unused0...), empty
+// keeps the disk name when it is free in the target and uses an unusedN entry otherwise.
+func (c *Client) ReassignVolume(volid string, targetVmid int, targetDisk string) (exitStatus string, err error) {
+	version, err := c.GetVersion()
+	if err != nil {
+		return "", err
+	}
+	if !version.AtLeast(7, 1) {
+		return "", fmt.Errorf("reassigning volumes needs PVE 7.1 or later, cluster runs %s", version)
+	}
+	_, volumeName := getStorageAndVolumeName(volid, ":")
+	match := rxVolumeOwner.FindStringSubmatch(volumeName)
+	if match == nil {
+		return "", fmt.Errorf("cannot find the owner of volume '%s'", volid)
+	}
+	ownerVmid, _ := strconv.Atoi(match[1])
+	sourceVmr := NewVmRef(ownerVmid)
+	sourceConfig, err := c.GetVmConfig(sourceVmr)
+	if err != nil {
+		return "", err
+	}
+	if sourceVmr.vmType != "qemu" {
+		return "", fmt.Errorf("volume '%s' belongs to container %d, only VM volumes can be reassigned", volid, ownerVmid)
+	}
+	disk := ""
+	for key, value := range sourceConfig {
+		if conf, ok := value.(string); ok && strings.SplitN(conf, ",", 2)[0] == volid {
+			disk = key
+			break
+		}
+	}
+	if disk == "" {
+		return "", fmt.Errorf("volume '%s' is not attached to VM %d", volid, ownerVmid)
+	}
+
+	targetVmr := NewVmRef(targetVmid)
+	targetConfig, err := c.GetVmConfig(targetVmr)
+	if err != nil {
+		return "", err
+	}
+	if targetVmr.node != sourceVmr.node {
+		return "", fmt.Errorf("VM %d is on %s and VM %d on %s, volumes can only be reassigned on a node", ownerVmid, sourceVmr.node, targetVmid, targetVmr.node)
+	}
+	if targetDisk == "" {
+		targetDisk = disk
+		for unusedID := 0; targetConfig[targetDisk] != nil; unusedID++ {
+			targetDisk = fmt.Sprintf("unused%d", unusedID)
+		}
+	}
+
+	reqbody := ParamsToBody(map[string]interface{}{
+		"disk":        disk,
+		"target-vmid": targetVmid,
+		"target-disk": targetDisk,
+	})
+	url := ApiPath("nodes", sourceVmr.node, "qemu", sourceVmr.vmId, "move_disk")
+	resp, err := c.session.Post(url, nil, nil, &reqbody)
+	if err == nil {
+		taskResponse := ResponseJSON(resp)
+		exitStatus, err = c.WaitForCompletion(taskResponse)
+	}
+	return
+}
@@ -0,0 +1,156 @@
+package proxmox
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// CloudImageOptions - how CreateVmFromCloudImage attaches and prepares the image.
+type CloudImageOptions struct {
+	// File storage the image is downloaded to when given as URL, `local` when empty.
+	ImageStorage string
+	// Device of the boot disk, `scsi0` when empty.
+	Disk string
+	// Final size of the boot disk like `20G`, the image size is kept when empty.
+	DiskSize string
+	// Device of the cloud-init drive, `ide2` when empty.
+	CloudInitDrive string
+	// Start the VM once provisioned.
+	Start bool
+}
+
+// CreateVmFromCloudImage - Create a VM on node booting from a cloud image, disks are allocated on storage.
+// image is a volid already on storage (`local:import/debian-12.qcow2`) or an http(s) URL downloaded first.
+// The VM is created from config without cloud-init options, then the image is imported as boot disk,
+// a cloud-init drive is added with the cloud-init options of config, and the disk is resized.
+// When one of these steps fails the VM is deleted, ErrCreationLeftovers reports a VM that could not be.
+// A failed start leaves the provisioned VM in place.
+func (c *Client) CreateVmFromCloudImage(node string, storage string, image string, config ConfigQemu, options CloudImageOptions) (vmr *VmRef, err error) {
+	if options.Disk == "" {
+		options.Disk = "scsi0"
+	}
+	if options.CloudInitDrive == "" {
+		options.CloudInitDrive = "ide2"
+	}
+	if options.Disk == options.CloudInitDrive {
+		return nil, fmt.Errorf("the boot disk and the cloud-init drive are both %s", options.Disk)
+	}
+	// Devices of config would be replaced by the boot disk or the cloud-init drive.
+	devices := config.deviceNames()
+	for _, device := range []string{options.Disk, options.CloudInitDrive} {
+		if inArray(devices, device) {
+			return nil, fmt.Errorf("%s is already used by the VM config", device)
+		}
+	}
+
+	volid := image
+	if strings.HasPrefix(image, "http://") || strings.HasPrefix(image, "https://") {
+		volid, err = c.downloadCloudImage(node, image, options.ImageStorage)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	nextid, err := c.GetNextID(0)
+	if err != nil {
+		return nil, err
+	}
+	vmr = NewVmRef(nextid)
+	vmr.SetNode(node)
+
+	// Cloud-init options are only accepted once the VM exists.
+	vmConfig := config
+	vmConfig.CIuser, vmConfig.CIpassword = "", ""
+	vmConfig.Searchdomain, vmConfig.Nameserver, vmConfig.Sshkeys = "", "", ""
+	vmConfig.Ipconfig0, vmConfig.Ipconfig1 = "", ""
+	if vmConfig.QemuDisks == nil {
+		vmConfig.QemuDisks = QemuDevices{}
+	}
+	if vmConfig.QemuNetworks == nil {
+		vmConfig.QemuNetworks = QemuDevices{}
+	}
+	err = vmConfig.CreateVm(vmr, c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.provisionCloudImageVm(vmr, storage, volid, config, options)
+	if err != nil {
+		return nil, c.discardVm(vmr, err)
+	}
+
+	if options.Start {
+		_, err = c.StartVm(vmr)
+	}
+	return
+}
+
+// provisionCloudImageVm - import the image as boot disk of vmr and add its cloud-init drive.
+func (c *Client) provisionCloudImageVm(vmr *VmRef, storage string, volid string, config ConfigQemu, options CloudImageOptions) (err error) {
+	_, err = c.ImportDisk(vmr, options.Disk, storage, volid)
+	if err != nil {
+		return err
+	}
+
+	configParams := map[string]interface{}{
+		options.CloudInitDrive: storage + ":cloudinit",
+		"boot":                 "order=" + options.Disk,
+	}
+	if strings.HasPrefix(options.Disk, "scsi") {
+		configParams[ConfigKeyScsiHw] = ScsiHwVirtio
+	}
+	config.CreateQemuCloudInitParams(configParams)
+	_, err = c.SetVmConfig(vmr, configParams)
+	if err != nil {
+		return err
+	}
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(GetString(vmConfig, options.CloudInitDrive), "cloudinit") {
+		return fmt.Errorf("cloud-init drive %s missing from VM %d", options.CloudInitDrive, vmr.vmId)
+	}
+
+	if options.DiskSize != "" {
+		_, err = c.ResizeQemuDiskTo(vmr, options.Disk, options.DiskSize)
+	}
+	return
+}
+
+// discardVm - delete vmr, created by an operation failing with cause. Returns cause, or
+// ErrCreationLeftovers when the VM could not be deleted.
+func (c *Client) discardVm(vmr *VmRef, cause error) error {
+	exitStatus, err := c.DeleteVm(vmr)
+	if err == nil && exitStatus != exitStatusSuccess {
+		err = errors.New(exitStatus)
+	}
+	if err != nil {
+		return &ErrCreationLeftovers{VmId: vmr.vmId, Node: vmr.node, VmExists: true, Cause: cause, Cleanup: []error{err}}
+	}
+	return cause
+}
+
+// downloadCloudImage - download imageUrl as import content, returns the volid of the image.
+func (c *Client) downloadCloudImage(node string, imageUrl string, imageStorage string) (volid string, err error) {
+	if imageStorage == "" {
+		imageStorage = "local"
+	}
+	parsedUrl, err := url.Parse(imageUrl)
+	if err != nil {
+		return "", err
+	}
+	filename := path.Base(parsedUrl.Path)
+	// Import content only accepts disk image extensions, cloud images are often published as .img (qcow2).
+	if strings.HasSuffix(filename, ".img") {
+		filename = strings.TrimSuffix(filename, ".img") + ".qcow2"
+	}
+	_, err = c.DownloadUrl(node, imageStorage, "import", filename, imageUrl)
+	if err != nil {
+		return "", err
+	}
+	return imageStorage + ":import/" + filename, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"math/rand"
 	"net"
 	"net/url"
@@ -27,6 +28,7 @@ type (
 type ConfigQemu struct {
 	Name         string      `json:"name"`
 	Description  string      `json:"desc"`
+	Pool         string      `json:"pool"`
 	Onboot       bool        `json:"onboot"`
 	Memory       int         `json:"memory"`
 	QemuOs       string      `json:"os"`
@@ -37,6 +39,46 @@ type ConfigQemu struct {
 	QemuDisks    QemuDevices `json:"disk"`
 	QemuNetworks QemuDevices `json:"network"`
 
+	// Create disks on storage before the VM instead of letting Proxmox allocate them.
+	PreallocateDisks bool `json:"preallocate_disks"`
+
+	// Boot and startup/shutdown ordering.
+	BootOrder []string     `json:"boot"`
+	Startup   *QemuStartup `json:"startup"`
+
+	// Serial ports (socket or host device path), RNG and audio devices.
+	QemuSerials map[int]string `json:"serial"`
+	QemuRng     *QemuRng       `json:"rng"`
+	QemuAudio   *QemuAudio     `json:"audio"`
+
+	// Machine type, firmware and architecture.
+	QemuMachine string       `json:"machine"`
+	QemuBios    string       `json:"bios"`
+	QemuArch    string       `json:"arch"`
+	QemuEfiDisk *QemuEfiDisk `json:"efidisk"`
+
+	// Extra QEMU command line flags, root@pam only. Requires Configuration.AllowQemuArgs.
+	QemuArgs string `json:"args"`
+
+	// Guest agent, RTC in local time (Windows) and CD-ROM drives besides the install ISO, keyed by device (ide0, sata1...).
+	QemuAgent     *QemuAgent        `json:"agent"`
+	QemuLocaltime bool              `json:"localtime"`
+	QemuCdroms    map[string]string `json:"cdroms"`
+
+	// virtiofs shares of cluster directory mappings (PVE 8.4+), keyed by device number.
+	QemuVirtiofs map[int]*QemuVirtiofs `json:"virtiofs"`
+
+	// Minimum memory in MB for ballooning (0 keeps the default), NUMA and hotplug categories (`network,disk,usb,memory,cpu`).
+	QemuBalloon int    `json:"balloon"`
+	QemuNuma    bool   `json:"numa"`
+	QemuHotplug string `json:"hotplug"`
+
+	// SCSI controller model (ScsiHw* constants), empty keeps the Proxmox default (lsi).
+	QemuScsiHw string `json:"scsihw"`
+
+	// Initial RTC date, `now` or `2006-01-02` / `2006-01-02T15:04:05`, empty for the host clock.
+	QemuStartDate string `json:"startdate"`
+
 	// Deprecated single disk.
 	DiskSize    float64 `json:"diskGB"`
 	Storage     string  `json:"storage"`
@@ -66,13 +108,16 @@ func (config ConfigQemu) CreateVm(vmr *VmRef, client *Client) (err error) {
 	if config.HasCloudInit() {
 		return errors.New("Cloud-init parameters only supported on clones or updates")
 	}
+	err = config.Validate()
+	if err != nil {
+		return
+	}
 	vmr.SetVmType("qemu")
 
 	params := map[string]interface{}{
 		"vmid":        vmr.vmId,
 		"name":        config.Name,
 		"onboot":      config.Onboot,
-		"ide2":        config.QemuIso + ",media=cdrom",
 		"ostype":      config.QemuOs,
 		"sockets":     config.QemuSockets,
 		"cores":       config.QemuCores,
@@ -81,16 +126,120 @@ func (config ConfigQemu) CreateVm(vmr *VmRef, client *Client) (err error) {
 		"description": config.Description,
 	}
 
-	// Create disks config.
-	config.CreateQemuDisksParams(vmr.vmId, params)
+	if config.QemuIso != "" {
+		params["ide2"] = config.QemuIso + ",media=cdrom"
+	}
+	if config.Pool != "" {
+		err = client.CheckPoolQuota(config.Pool, config.quotaRequest())
+		if err != nil {
+			return
+		}
+		params["pool"] = config.Pool
+	}
+
+	// Create disks config, new disks are allocated by Proxmox unless preallocated.
+	config.createQemuDisksParams(vmr.vmId, params, !config.PreallocateDisks)
 
 	// Create networks config.
 	config.CreateQemuNetworksParams(vmr.vmId, params)
 
-	_, err = client.CreateQemuVm(vmr.node, params)
+	// Boot order and startup/shutdown ordering.
+	err = config.CreateQemuBootParams(params)
+	if err != nil {
+		return
+	}
+
+	// Serial ports, RNG and audio devices.
+	err = config.CreateQemuExtraDevicesParams(params)
+	if err != nil {
+		return
+	}
+
+	// Machine type, firmware and architecture.
+	err = config.CreateQemuMachineParams(params)
+	if err != nil {
+		return
+	}
+
+	// Raw QEMU arguments.
+	err = config.CreateQemuArgsParams(params, client.configuration.AllowQemuArgs)
+	if err != nil {
+		return
+	}
+
+	// Guest agent, localtime and extra CD-ROM drives.
+	err = config.CreateQemuGuestParams(params)
+	if err != nil {
+		return
+	}
+
+	// virtiofs shares.
+	err = config.CreateQemuVirtiofsParams(params)
+	if err != nil {
+		return
+	}
+
+	// Balloon, NUMA and hotplug.
+	err = config.CreateQemuMemoryParams(params)
+	if err != nil {
+		return
+	}
+
+	// SCSI controller model.
+	err = config.CreateQemuScsiHwParams(params)
+	if err != nil {
+		return
+	}
+
+	// RTC start date.
+	err = config.CreateQemuRtcParams(params)
+	if err != nil {
+		return
+	}
+
+	options := QemuCreateOptions{
+		VmId:    vmr.vmId,
+		Name:    config.Name,
+		Memory:  config.Memory,
+		Cores:   config.QemuCores,
+		Sockets: config.QemuSockets,
+		Params:  params,
+
+		PreallocateDisks: config.PreallocateDisks,
+	}
+	_, err = client.CreateQemuVmWithOptions(vmr.node, options)
 	return
 }
 
+// Create cloud-init parameters, only options that are set are sent.
+func (c ConfigQemu) CreateQemuCloudInitParams(params map[string]interface{}) {
+	if c.CIuser != "" {
+		params["ciuser"] = c.CIuser
+	}
+	if c.CIpassword != "" {
+		params["cipassword"] = c.CIpassword
+	}
+	if c.Searchdomain != "" {
+		params["searchdomain"] = c.Searchdomain
+	}
+	if c.Nameserver != "" {
+		params["nameserver"] = c.Nameserver
+	}
+	if c.Sshkeys != "" {
+		sshkeyEnc := url.PathEscape(c.Sshkeys + "\n")
+		sshkeyEnc = strings.Replace(sshkeyEnc, "+", "%2B", -1)
+		sshkeyEnc = strings.Replace(sshkeyEnc, "@", "%40", -1)
+		sshkeyEnc = strings.Replace(sshkeyEnc, "=", "%3D", -1)
+		params["sshkeys"] = sshkeyEnc
+	}
+	if c.Ipconfig0 != "" {
+		params["ipconfig0"] = c.Ipconfig0
+	}
+	if c.Ipconfig1 != "" {
+		params["ipconfig1"] = c.Ipconfig1
+	}
+}
+
 // HasCloudInit - are there cloud-init options?
 func (config ConfigQemu) HasCloudInit() bool {
 	return config.CIuser != "" ||
@@ -133,10 +282,31 @@ func (config ConfigQemu) CloneVm(sourceVmr *VmRef, vmr *VmRef, client *Client) (
 		"storage": storage,
 		"full":    fullclone,
 	}
+	// Full clones place each disk on its own storage and format when they differ.
+	if fullclone == "1" {
+		if targets := config.cloneDiskTargets(storage); len(targets) > 0 {
+			return client.CloneQemuVmToDisks(sourceVmr, vmr, params, targets)
+		}
+	}
 	_, err = client.CloneQemuVm(sourceVmr, params)
 	return
 }
 
+// cloneDiskTargets - storage and format of the configured disks that the clone to storage does not give them.
+func (config ConfigQemu) cloneDiskTargets(storage string) map[string]CloneDiskTarget {
+	targets := map[string]CloneDiskTarget{}
+	for diskID, diskConfMap := range config.QemuDisks {
+		deviceType, _ := diskConfMap["type"].(string)
+		diskStorage, _ := diskConfMap["storage"].(string)
+		format, _ := diskConfMap["format"].(string)
+		if deviceType == "" || ((diskStorage == "" || diskStorage == storage) && format == "") {
+			continue
+		}
+		targets[deviceType+strconv.Itoa(diskID)] = CloneDiskTarget{Storage: diskStorage, Format: format}
+	}
+	return targets
+}
+
 func (config ConfigQemu) UpdateConfig(vmr *VmRef, client *Client) (err error) {
 	configParams := map[string]interface{}{
 		"description": config.Description,
@@ -152,32 +322,69 @@ func (config ConfigQemu) UpdateConfig(vmr *VmRef, client *Client) (err error) {
 	// Create networks config.
 	config.CreateQemuNetworksParams(vmr.vmId, configParams)
 
-	// cloud-init options
-	if config.CIuser != "" {
-		configParams["ciuser"] = config.CIuser
+	// Boot order and startup/shutdown ordering.
+	err = config.CreateQemuBootParams(configParams)
+	if err != nil {
+		return
 	}
-	if config.CIpassword != "" {
-		configParams["cipassword"] = config.CIpassword
+
+	// Serial ports, RNG and audio devices.
+	err = config.CreateQemuExtraDevicesParams(configParams)
+	if err != nil {
+		return
 	}
-	if config.Searchdomain != "" {
-		configParams["searchdomain"] = config.Searchdomain
+
+	// Machine type, firmware and architecture.
+	err = config.CreateQemuMachineParams(configParams)
+	if err != nil {
+		return
 	}
-	if config.Nameserver != "" {
-		configParams["nameserver"] = config.Nameserver
+	// The EFI disk is allocated at creation, sending it again would allocate a new one.
+	delete(configParams, "efidisk0")
+
+	// Raw QEMU arguments.
+	err = config.CreateQemuArgsParams(configParams, client.configuration.AllowQemuArgs)
+	if err != nil {
+		return
 	}
-	if config.Sshkeys != "" {
-		sshkeyEnc := url.PathEscape(config.Sshkeys + "\n")
-		sshkeyEnc = strings.Replace(sshkeyEnc, "+", "%2B", -1)
-		sshkeyEnc = strings.Replace(sshkeyEnc, "@", "%40", -1)
-		sshkeyEnc = strings.Replace(sshkeyEnc, "=", "%3D", -1)
-		configParams["sshkeys"] = sshkeyEnc
+
+	// Guest agent, localtime and extra CD-ROM drives.
+	err = config.CreateQemuGuestParams(configParams)
+	if err != nil {
+		return
+	}
+
+	// virtiofs shares.
+	err = config.CreateQemuVirtiofsParams(configParams)
+	if err != nil {
+		return
 	}
-	if config.Ipconfig0 != "" {
-		configParams["ipconfig0"] = config.Ipconfig0
+
+	// Balloon, NUMA and hotplug.
+	err = config.CreateQemuMemoryParams(configParams)
+	if err != nil {
+		return
 	}
-	if config.Ipconfig1 != "" {
-		configParams["ipconfig1"] = config.Ipconfig1
+
+	// SCSI controller model. Existing VMs may already run iothread disks on another controller,
+	// the conflict only fails creations and Validate.
+	err = config.createQemuScsiHwParam(configParams)
+	if err != nil {
+		return
 	}
+	if iothreadErr := config.checkScsiHwIothread(); iothreadErr != nil {
+		log.Println("Warning:", iothreadErr)
+	}
+
+	// RTC start date.
+	err = config.CreateQemuRtcParams(configParams)
+	if err != nil {
+		return
+	}
+
+	// cloud-init options
+	config.CreateQemuCloudInitParams(configParams)
+
 	_, err = client.SetVmConfig(vmr, configParams)
 	return err
 }
@@ -239,34 +446,22 @@ func NewConfigQemuFromApi(vmr *VmRef, client *Client) (config *ConfigQemu, err e
 	if _, isSet := vmConfig["description"]; isSet {
 		description = vmConfig["description"].(string)
 	}
-	onboot := true
-	if _, isSet := vmConfig["onboot"]; isSet {
-		onboot = Itob(int(vmConfig["onboot"].(float64)))
-	}
+	onboot := Itob(GetIntDefault(vmConfig, "onboot", 1))
 	ostype := "other"
 	if _, isSet := vmConfig["ostype"]; isSet {
 		ostype = vmConfig["ostype"].(string)
 	}
-	memory := 0.0
-	if _, isSet := vmConfig["memory"]; isSet {
-		memory = vmConfig["memory"].(float64)
-	}
-	cores := 1.0
-	if _, isSet := vmConfig["cores"]; isSet {
-		cores = vmConfig["cores"].(float64)
-	}
-	sockets := 1.0
-	if _, isSet := vmConfig["sockets"]; isSet {
-		sockets = vmConfig["sockets"].(float64)
-	}
+	memory := GetIntDefault(vmConfig, "memory", 0)
+	cores := GetIntDefault(vmConfig, "cores", 1)
+	sockets := GetIntDefault(vmConfig, "sockets", 1)
 	config = &ConfigQemu{
 		Name:         name,
 		Description:  strings.TrimSpace(description),
 		Onboot:       onboot,
 		QemuOs:       ostype,
-		Memory:       int(memory),
-		QemuCores:    int(cores),
-		QemuSockets:  int(sockets),
+		Memory:       memory,
+		QemuCores:    cores,
+		QemuSockets:  sockets,
 		QemuVlanTag:  -1,
 		QemuDisks:    QemuDevices{},
 		QemuNetworks: QemuDevices{},
@@ -277,6 +472,79 @@ func NewConfigQemuFromApi(vmr *VmRef, client *Client) (config *ConfigQemu, err e
 		config.QemuIso = isoMatch[1]
 	}
 
+	if _, isSet := vmConfig["boot"]; isSet {
+		config.BootOrder = ParseBootOrder(vmConfig["boot"].(string))
+	}
+	if _, isSet := vmConfig["startup"]; isSet {
+		config.Startup = ParseQemuStartup(vmConfig["startup"].(string))
+	}
+
+	for k, v := range vmConfig {
+		if serialName := rxSerialName.FindStringSubmatch(k); len(serialName) > 0 {
+			serialID, _ := strconv.Atoi(serialName[1])
+			if config.QemuSerials == nil {
+				config.QemuSerials = map[int]string{}
+			}
+			config.QemuSerials[serialID] = v.(string)
+		}
+	}
+	if _, isSet := vmConfig["rng0"]; isSet {
+		config.QemuRng = ParseQemuRng(vmConfig["rng0"].(string))
+	}
+	if _, isSet := vmConfig["audio0"]; isSet {
+		config.QemuAudio = ParseQemuAudio(vmConfig["audio0"].(string))
+	}
+
+	if _, isSet := vmConfig["machine"]; isSet {
+		config.QemuMachine = vmConfig["machine"].(string)
+	}
+	if _, isSet := vmConfig["bios"]; isSet {
+		config.QemuBios = vmConfig["bios"].(string)
+	}
+	if _, isSet := vmConfig["arch"]; isSet {
+		config.QemuArch = vmConfig["arch"].(string)
+	}
+	if _, isSet := vmConfig["efidisk0"]; isSet {
+		config.QemuEfiDisk = ParseQemuEfiDisk(vmConfig["efidisk0"].(string))
+	}
+
+	if _, isSet := vmConfig["args"]; isSet {
+		config.QemuArgs = vmConfig["args"].(string)
+	}
+
+	if _, isSet := vmConfig["agent"]; isSet {
+		config.QemuAgent = ParseQemuAgent(GetString(vmConfig, "agent"))
+	}
+	config.QemuLocaltime = Itob(GetIntDefault(vmConfig, "localtime", 0))
+	for k, v := range vmConfig {
+		if rxCdromName.MatchString(k) && k != "ide2" {
+			if cdromConf, ok := v.(string); ok && strings.Contains(cdromConf, "media=cdrom") {
+				if config.QemuCdroms == nil {
+					config.QemuCdroms = map[string]string{}
+				}
+				config.QemuCdroms[k] = strings.Split(cdromConf, ",")[0]
+			}
+		}
+	}
+
+	for k, v := range vmConfig {
+		if virtiofsName := rxVirtiofsName.FindStringSubmatch(k); len(virtiofsName) > 0 {
+			virtiofsID, _ := strconv.Atoi(virtiofsName[1])
+			if config.QemuVirtiofs == nil {
+				config.QemuVirtiofs = map[int]*QemuVirtiofs{}
+			}
+			config.QemuVirtiofs[virtiofsID] = ParseQemuVirtiofs(v.(string))
+		}
+	}
+
+	config.QemuBalloon = GetIntDefault(vmConfig, "balloon", 0)
+	config.QemuNuma = Itob(GetIntDefault(vmConfig, "numa", 0))
+	config.QemuHotplug = GetString(vmConfig, "hotplug")
+
+	config.QemuScsiHw = GetString(vmConfig, ConfigKeyScsiHw)
+
+	config.QemuStartDate = GetString(vmConfig, "startdate")
+
 	if _, isSet := vmConfig["ciuser"]; isSet {
 		config.CIuser = vmConfig["ciuser"].(string)
 	}
@@ -429,12 +697,13 @@ func RemoveSshForwardUsernet(vmr *VmRef, client *Client) (err error) {
 }
 
 func MaxVmId(client *Client) (max int, err error) {
-	resp, err := client.GetVmList()
-	vms := resp["data"].([]interface{})
+	vms, err := client.GetVmResources()
+	if err != nil {
+		return 0, err
+	}
 	max = 0
-	for vmii := range vms {
-		vm := vms[vmii].(map[string]interface{})
-		vmid := int(vm["vmid"].(float64))
+	for _, vm := range vms {
+		vmid := int(vm.VmId)
 		if vmid > max {
 			max = vmid
 		}
@@ -580,6 +849,16 @@ func (c ConfigQemu) CreateQemuDisksParams(
 	vmID int,
 	params map[string]interface{},
 ) error {
+	return c.createQemuDisksParams(vmID, params, false)
+}
+
+// createQemuDisksParams - with autoAllocate, disks use the `storage:size` syntax so Proxmox allocates them,
+// otherwise they reference the file name Proxmox would give them.
+func (c ConfigQemu) createQemuDisksParams(
+	vmID int,
+	params map[string]interface{},
+	autoAllocate bool,
+) error {
 
 	// For backward compatibility.
 	if len(c.QemuDisks) == 0 && len(c.Storage) > 0 {
@@ -603,23 +882,25 @@ func (c ConfigQemu) CreateQemuDisksParams(
 		deviceType := diskConfMap["type"].(string)
 		qemuDiskName := deviceType + strconv.Itoa(diskID)
 
-		// Set disk storage.
-		// Disk size.
-		diskSizeGB := fmt.Sprintf("size=%v", diskConfMap["size"])
-		diskConfParam = append(diskConfParam, diskSizeGB)
-
-		// Disk name.
-		var diskFile string
-		// Currently ZFS local, LVM, and Directory are considered.
-		// Other formats are not verified, but could be added if they're needed.
-		rxStorageTypes := `(zfspool|lvm)`
-		storageType := diskConfMap["storage_type"].(string)
-		if matched, _ := regexp.MatchString(rxStorageTypes, storageType); matched {
-			diskFile = fmt.Sprintf("file=%v:vm-%v-disk-%v", diskConfMap["storage"], vmID, diskID)
+		if importFrom, isSet := diskConfMap["import_from"].(string); isSet && importFrom != "" {
+			// Let Proxmox allocate the disk and fill it from an existing image (qcow2/raw/vmdk) on storage.
+			diskConfParam = append(diskConfParam, fmt.Sprintf("file=%v:0", diskConfMap["storage"]))
+			diskConfParam = append(diskConfParam, fmt.Sprintf("import-from=%v", importFrom))
+		} else if autoAllocate {
+			// Let Proxmox allocate a new disk of the requested size (in GB) on storage.
+			diskConfParam = append(diskConfParam, fmt.Sprintf("file=%v:%v", diskConfMap["storage"], diskAllocationGB(diskConfMap["size"])))
 		} else {
-			diskFile = fmt.Sprintf("file=%v:%v/vm-%v-disk-%v.%v", diskConfMap["storage"], vmID, vmID, diskID, diskConfMap["format"])
+			// Set disk storage.
+			// Disk size.
+			diskSizeGB := fmt.Sprintf("size=%v", diskConfMap["size"])
+			diskConfParam = append(diskConfParam, diskSizeGB)
+
+			// Disk name.
+			storageType, _ := diskConfMap["storage_type"].(string)
+			format, _ := diskConfMap["format"].(string)
+			diskFile := fmt.Sprintf("file=%v:%v", diskConfMap["storage"], DiskName(storageType, vmID, diskID, format))
+			diskConfParam = append(diskConfParam, diskFile)
 		}
-		diskConfParam = append(diskConfParam, diskFile)
 
 		// Set cache if not none (default).
 		if diskConfMap["cache"].(string) != "none" {
@@ -628,7 +909,7 @@ func (c ConfigQemu) CreateQemuDisksParams(
 		}
 
 		// Keys that are not used as real/direct conf.
-		ignoredKeys := []string{"id", "type", "storage", "storage_type", "size", "cache"}
+		ignoredKeys := []string{"id", "type", "storage", "storage_type", "size", "cache", "import_from"}
 
 		// Rest of config.
 		diskConfParam = diskConfParam.createDeviceParam(diskConfMap, ignoredKeys)
@@ -640,6 +921,614 @@ func (c ConfigQemu) CreateQemuDisksParams(
 	return nil
 }
 
+// QemuStartup - startup/shutdown ordering, delays are in seconds.
+type QemuStartup struct {
+	Order int `json:"order"`
+	Up    int `json:"up"`
+	Down  int `json:"down"`
+}
+
+// String - startup parameter in Proxmox format `order=1,up=30,down=60`.
+func (s QemuStartup) String() string {
+	startup := QemuDeviceParam{}
+	if s.Order > 0 {
+		startup = append(startup, fmt.Sprintf("order=%d", s.Order))
+	}
+	if s.Up > 0 {
+		startup = append(startup, fmt.Sprintf("up=%d", s.Up))
+	}
+	if s.Down > 0 {
+		startup = append(startup, fmt.Sprintf("down=%d", s.Down))
+	}
+	return strings.Join(startup, ",")
+}
+
+// ParseQemuStartup - read the startup parameter, unknown keys are ignored.
+func ParseQemuStartup(startup string) *QemuStartup {
+	confMap := ParseConf(startup, ",", "=")
+	s := &QemuStartup{}
+	s.Order, _ = confMap["order"].(int)
+	s.Up, _ = confMap["up"].(int)
+	s.Down, _ = confMap["down"].(int)
+	return s
+}
+
+// ParseBootOrder - read the boot parameter `order=scsi0;net0`.
+// The legacy `cdn` format does not name devices and is returned as an empty order.
+func ParseBootOrder(boot string) []string {
+	bootOrder := []string{}
+	for _, item := range strings.Split(boot, ",") {
+		if strings.HasPrefix(item, "order=") {
+			for _, device := range strings.Split(strings.TrimPrefix(item, "order="), ";") {
+				if device != "" {
+					bootOrder = append(bootOrder, device)
+				}
+			}
+		}
+	}
+	return bootOrder
+}
+
+// deviceNames - names of the devices (virtio0, net1, ide2...) defined by the config.
+func (c ConfigQemu) deviceNames() []string {
+	names := []string{}
+	for diskID, diskConfMap := range c.QemuDisks {
+		if deviceType, ok := diskConfMap["type"].(string); ok {
+			names = append(names, deviceType+strconv.Itoa(diskID))
+		}
+	}
+	for nicID := range c.QemuNetworks {
+		names = append(names, "net"+strconv.Itoa(nicID))
+	}
+	if c.QemuIso != "" {
+		names = append(names, "ide2")
+	}
+	for device := range c.QemuCdroms {
+		names = append(names, device)
+	}
+	return names
+}
+
+// Create boot order and startup parameters, boot devices must be defined in the config.
+func (c ConfigQemu) CreateQemuBootParams(params map[string]interface{}) error {
+	if len(c.BootOrder) > 0 {
+		devices := c.deviceNames()
+		for _, device := range c.BootOrder {
+			if !inArray(devices, device) {
+				return fmt.Errorf("boot device '%s' is not defined in the VM config", device)
+			}
+		}
+		params["boot"] = "order=" + strings.Join(c.BootOrder, ";")
+	}
+	if c.Startup != nil {
+		if c.Startup.Order < 0 || c.Startup.Up < 0 || c.Startup.Down < 0 {
+			return errors.New("startup order and delays must be positive")
+		}
+		params["startup"] = c.Startup.String()
+	}
+	return nil
+}
+
+var rxSerialName = regexp.MustCompile(`^serial(\d+)$`)
+
+// QemuRng - VirtIO random number generator, limited to MaxBytes per Period milliseconds.
+type QemuRng struct {
+	Source   string `json:"source"`
+	MaxBytes int    `json:"max_bytes"`
+	Period   int    `json:"period"`
+}
+
+// QemuAudio - audio device, Driver is `spice` or `none`.
+type QemuAudio struct {
+	Device string `json:"device"`
+	Driver string `json:"driver"`
+}
+
+var (
+	rngSources   = []string{"/dev/urandom", "/dev/random", "/dev/hwrng"}
+	audioDevices = []string{"ich9-intel-hda", "intel-hda", "AC97"}
+	audioDrivers = []string{"spice", "none"}
+)
+
+// String - rng0 parameter in Proxmox format.
+func (rng QemuRng) String() string {
+	rngParam := QemuDeviceParam{"source=" + rng.Source}
+	if rng.MaxBytes > 0 {
+		rngParam = append(rngParam, fmt.Sprintf("max_bytes=%d", rng.MaxBytes))
+	}
+	if rng.Period > 0 {
+		rngParam = append(rngParam, fmt.Sprintf("period=%d", rng.Period))
+	}
+	return strings.Join(rngParam, ",")
+}
+
+// ParseQemuRng - read the rng0 parameter.
+func ParseQemuRng(rng string) *QemuRng {
+	confMap := ParseConf(rng, ",", "=")
+	r := &QemuRng{}
+	r.Source, _ = confMap["source"].(string)
+	r.MaxBytes, _ = confMap["max_bytes"].(int)
+	r.Period, _ = confMap["period"].(int)
+	return r
+}
+
+// String - audio0 parameter in Proxmox format.
+func (audio QemuAudio) String() string {
+	audioParam := QemuDeviceParam{"device=" + audio.Device}
+	if audio.Driver != "" {
+		audioParam = append(audioParam, "driver="+audio.Driver)
+	}
+	return strings.Join(audioParam, ",")
+}
+
+// ParseQemuAudio - read the audio0 parameter.
+func ParseQemuAudio(audio string) *QemuAudio {
+	confMap := ParseConf(audio, ",", "=")
+	a := &QemuAudio{}
+	a.Device, _ = confMap["device"].(string)
+	a.Driver, _ = confMap["driver"].(string)
+	return a
+}
+
+// Create serial ports, RNG and audio parameters.
+func (c ConfigQemu) CreateQemuExtraDevicesParams(params map[string]interface{}) error {
+	for serialID, serial := range c.QemuSerials {
+		if serialID < 0 || serialID > 3 {
+			return fmt.Errorf("serial port id %d out of range 0-3", serialID)
+		}
+		if serial != "socket" && !strings.HasPrefix(serial, "/dev/") {
+			return fmt.Errorf("serial%d must be 'socket' or a /dev path, got '%s'", serialID, serial)
+		}
+		params["serial"+strconv.Itoa(serialID)] = serial
+	}
+	if c.QemuRng != nil {
+		if !inArray(rngSources, c.QemuRng.Source) {
+			return fmt.Errorf("rng source must be one of %s", strings.Join(rngSources, ", "))
+		}
+		params["rng0"] = c.QemuRng.String()
+	}
+	if c.QemuAudio != nil {
+		if !inArray(audioDevices, c.QemuAudio.Device) {
+			return fmt.Errorf("audio device must be one of %s", strings.Join(audioDevices, ", "))
+		}
+		if c.QemuAudio.Driver != "" && !inArray(audioDrivers, c.QemuAudio.Driver) {
+			return fmt.Errorf("audio driver must be one of %s", strings.Join(audioDrivers, ", "))
+		}
+		params["audio0"] = c.QemuAudio.String()
+	}
+	return nil
+}
+
+// Machine families, BIOS and architectures accepted by Proxmox.
+const (
+	MachineI440fx = "i440fx"
+	MachineQ35    = "q35"
+
+	BiosSeabios = "seabios"
+	BiosOvmf    = "ovmf"
+
+	ArchX86_64  = "x86_64"
+	ArchAarch64 = "aarch64"
+)
+
+// Matches `pc`, `q35`, `pc-i440fx-8.1`, `pc-q35-7.2+pve1` and `virt`, optionally followed by `,viommu=...`.
+var rxMachine = regexp.MustCompile(`^(pc|q35|virt|pc-(i440fx|q35)-\d+\.\d+(\+pve\d+)?)(,.*)?$`)
+
+// QemuMachineType - machine parameter for a family (i440fx or q35), pinned to a QEMU version when not empty.
+func QemuMachineType(family string, version string) string {
+	if family == MachineI440fx {
+		family = "pc"
+		if version != "" {
+			return "pc-i440fx-" + version
+		}
+	}
+	if version != "" {
+		return "pc-" + family + "-" + version
+	}
+	return family
+}
+
+// QemuEfiDisk - disk storing the OVMF EFI variables, EfiType is `2m` or `4m`.
+type QemuEfiDisk struct {
+	Storage         string `json:"storage"`
+	EfiType         string `json:"efitype"`
+	PreEnrolledKeys bool   `json:"pre_enrolled_keys"`
+}
+
+// String - efidisk0 parameter in Proxmox format, the disk is allocated by Proxmox.
+func (efi QemuEfiDisk) String() string {
+	efiParam := QemuDeviceParam{efi.Storage + ":1"}
+	if efi.EfiType != "" {
+		efiParam = append(efiParam, "efitype="+efi.EfiType)
+	}
+	if efi.PreEnrolledKeys {
+		efiParam = append(efiParam, "pre-enrolled-keys=1")
+	}
+	return strings.Join(efiParam, ",")
+}
+
+// ParseQemuEfiDisk - read the efidisk0 parameter.
+func ParseQemuEfiDisk(efidisk string) *QemuEfiDisk {
+	efiConfList := strings.Split(efidisk, ",")
+	storageName, _ := getStorageAndVolumeName(efiConfList[0], ":")
+	confMap := ParseConf(efidisk, ",", "=")
+	efi := &QemuEfiDisk{Storage: storageName}
+	efi.EfiType, _ = confMap["efitype"].(string)
+	if keys, ok := confMap["pre-enrolled-keys"].(int); ok {
+		efi.PreEnrolledKeys = Itob(keys)
+	}
+	return efi
+}
+
+// Create machine, bios and arch parameters, OVMF needs an EFI disk to store its variables.
+func (c ConfigQemu) CreateQemuMachineParams(params map[string]interface{}) error {
+	if c.QemuMachine != "" {
+		if !rxMachine.MatchString(c.QemuMachine) {
+			return fmt.Errorf("invalid machine type '%s'", c.QemuMachine)
+		}
+		params["machine"] = c.QemuMachine
+	}
+	if c.QemuBios != "" {
+		if c.QemuBios != BiosSeabios && c.QemuBios != BiosOvmf {
+			return fmt.Errorf("bios must be %s or %s", BiosSeabios, BiosOvmf)
+		}
+		if c.QemuBios == BiosOvmf && c.QemuEfiDisk == nil {
+			return errors.New("ovmf bios requires an efidisk")
+		}
+		params["bios"] = c.QemuBios
+	}
+	if c.QemuArch != "" {
+		if c.QemuArch != ArchX86_64 && c.QemuArch != ArchAarch64 {
+			return fmt.Errorf("arch must be %s or %s", ArchX86_64, ArchAarch64)
+		}
+		params["arch"] = c.QemuArch
+	}
+	if c.QemuEfiDisk != nil {
+		if c.QemuEfiDisk.EfiType != "" && c.QemuEfiDisk.EfiType != "2m" && c.QemuEfiDisk.EfiType != "4m" {
+			return errors.New("efitype must be 2m or 4m")
+		}
+		params["efidisk0"] = c.QemuEfiDisk.String()
+	}
+	return nil
+}
+
+// Create the args parameter, refused unless the client explicitly allows raw QEMU flags.
+func (c ConfigQemu) CreateQemuArgsParams(params map[string]interface{}, allowArgs bool) error {
+	if c.QemuArgs == "" {
+		return nil
+	}
+	if !allowArgs {
+		return errors.New("QEMU args passthrough is disabled, set AllowQemuArgs in the client configuration")
+	}
+	params["args"] = c.QemuArgs
+	return nil
+}
+
+// diskSizeGB - disk size like `30G`, `512M`, `1T` or a plain number of GB, as a number of GB.
+func diskSizeGB(size interface{}) (sizeGB float64, err error) {
+	sizeStr := strings.TrimSpace(fmt.Sprintf("%v", size))
+	if sizeStr == "" {
+		return 0, nil
+	}
+	value, unit := sizeStr, "G"
+	if last := sizeStr[len(sizeStr)-1:]; strings.Contains("KMGTkmgt", last) {
+		value, unit = sizeStr[:len(sizeStr)-1], strings.ToUpper(last)
+	}
+	sizeGB, err = strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid disk size '%s'", sizeStr)
+	}
+	switch unit {
+	case "K":
+		sizeGB = sizeGB / (1024 * 1024)
+	case "M":
+		sizeGB = sizeGB / 1024
+	case "T":
+		sizeGB = sizeGB * 1024
+	}
+	return
+}
+
+// diskAllocationGB - size of a disk allocated with the `storage:size` syntax, which only takes whole
+// GB: smaller units are rounded up. Sizes that do not parse are left for the API to reject.
+func diskAllocationGB(size interface{}) string {
+	sizeGB, err := diskSizeGB(size)
+	if err != nil {
+		return fmt.Sprint(size)
+	}
+	return strconv.Itoa(int(math.Ceil(sizeGB)))
+}
+
+var rxCdromName = regexp.MustCompile(`^(ide|sata|scsi)\d+$`)
+
+// QemuAgent - QEMU guest agent settings, Type is `virtio` (default) or `isa`.
+type QemuAgent struct {
+	Enabled           bool   `json:"enabled"`
+	FstrimClonedDisks bool   `json:"fstrim_cloned_disks"`
+	Type              string `json:"type"`
+}
+
+// String - agent parameter in Proxmox format.
+func (agent QemuAgent) String() string {
+	agentParam := QemuDeviceParam{"0"}
+	if agent.Enabled {
+		agentParam[0] = "1"
+	}
+	if agent.FstrimClonedDisks {
+		agentParam = append(agentParam, "fstrim_cloned_disks=1")
+	}
+	if agent.Type != "" {
+		agentParam = append(agentParam, "type="+agent.Type)
+	}
+	return strings.Join(agentParam, ",")
+}
+
+// ParseQemuAgent - read the agent parameter `1,fstrim_cloned_disks=1` or `enabled=1,type=virtio`.
+func ParseQemuAgent(agent string) *QemuAgent {
+	a := &QemuAgent{}
+	confMap := ParseConf(agent, ",", "=")
+	for _, item := range strings.Split(agent, ",") {
+		if item == "1" {
+			a.Enabled = true
+		}
+	}
+	if enabled, ok := confMap["enabled"].(int); ok {
+		a.Enabled = Itob(enabled)
+	}
+	if fstrim, ok := confMap["fstrim_cloned_disks"].(int); ok {
+		a.FstrimClonedDisks = Itob(fstrim)
+	}
+	a.Type, _ = confMap["type"].(string)
+	return a
+}
+
+// Windows ostype values accepted by Proxmox.
+var windowsOsTypes = []string{OsWxp, OsW2k, OsW2k3, OsW2k8, OsWvista, OsWin7, OsWin8, OsWin10, OsWin11}
+
+// IsWindowsOs - is ostype one of the Windows releases?
+func IsWindowsOs(ostype string) bool {
+	return inArray(windowsOsTypes, ostype)
+}
+
+// WindowsDefaults - preset for Windows guests: win11 ostype, RTC in local time, guest agent trimming
+// cloned disks and the VirtIO drivers ISO (e.g. `local:iso/virtio-win.iso`) attached as a second CD-ROM.
+// The install ISO, disks and networks are left to the caller.
+func WindowsDefaults(virtioIso string) ConfigQemu {
+	config := ConfigQemu{
+		QemuOs:        "win11",
+		QemuCores:     2,
+		QemuSockets:   1,
+		Memory:        4096,
+		QemuVlanTag:   -1,
+		QemuDisks:     QemuDevices{},
+		QemuNetworks:  QemuDevices{},
+		QemuLocaltime: true,
+		QemuAgent:     &QemuAgent{Enabled: true, FstrimClonedDisks: true},
+	}
+	if virtioIso != "" {
+		config.QemuCdroms = map[string]string{"ide0": virtioIso}
+	}
+	return config
+}
+
+// Create guest agent, localtime and extra CD-ROM parameters.
+func (c ConfigQemu) CreateQemuGuestParams(params map[string]interface{}) error {
+	if c.QemuAgent != nil {
+		if c.QemuAgent.Type != "" && c.QemuAgent.Type != "virtio" && c.QemuAgent.Type != "isa" {
+			return errors.New("agent type must be virtio or isa")
+		}
+		params["agent"] = c.QemuAgent.String()
+	}
+	if c.QemuLocaltime {
+		params["localtime"] = true
+	}
+	for device, iso := range c.QemuCdroms {
+		if !rxCdromName.MatchString(device) {
+			return fmt.Errorf("invalid CD-ROM device '%s'", device)
+		}
+		if device == "ide2" && c.QemuIso != "" {
+			return errors.New("CD-ROM device ide2 is used by the install ISO")
+		}
+		if _, isSet := params[device]; isSet {
+			return fmt.Errorf("CD-ROM device '%s' is already used", device)
+		}
+		params[device] = iso + ",media=cdrom"
+	}
+	return nil
+}
+
+var rxVirtiofsName = regexp.MustCompile(`^virtiofs(\d+)$`)
+
+// virtiofs cache modes accepted by Proxmox.
+var virtiofsCacheModes = []string{"auto", "always", "metadata", "never"}
+
+// QemuVirtiofs - host directory shared with the guest through virtiofs, DirId is the
+// id of a cluster directory mapping (see CreateDirMapping).
+type QemuVirtiofs struct {
+	DirId       string `json:"dirid"`
+	Cache       string `json:"cache"`
+	DirectIo    bool   `json:"direct_io"`
+	ExposeAcl   bool   `json:"expose_acl"`
+	ExposeXattr bool   `json:"expose_xattr"`
+}
+
+// String - virtiofs parameter in Proxmox format.
+func (share QemuVirtiofs) String() string {
+	shareParam := QemuDeviceParam{share.DirId}
+	if share.Cache != "" {
+		shareParam = append(shareParam, "cache="+share.Cache)
+	}
+	if share.DirectIo {
+		shareParam = append(shareParam, "direct-io=1")
+	}
+	if share.ExposeAcl {
+		shareParam = append(shareParam, "expose-acl=1")
+	}
+	if share.ExposeXattr {
+		shareParam = append(shareParam, "expose-xattr=1")
+	}
+	return strings.Join(shareParam, ",")
+}
+
+// ParseQemuVirtiofs - read a virtiofs parameter `share,cache=auto,expose-acl=1`.
+func ParseQemuVirtiofs(virtiofs string) *QemuVirtiofs {
+	share := &QemuVirtiofs{}
+	confMap := ParseConf(virtiofs, ",", "=")
+	share.DirId, _ = confMap["dirid"].(string)
+	if share.DirId == "" {
+		share.DirId = strings.Split(virtiofs, ",")[0]
+	}
+	share.Cache, _ = confMap["cache"].(string)
+	if directIo, ok := confMap["direct-io"].(int); ok {
+		share.DirectIo = Itob(directIo)
+	}
+	if exposeAcl, ok := confMap["expose-acl"].(int); ok {
+		share.ExposeAcl = Itob(exposeAcl)
+	}
+	if exposeXattr, ok := confMap["expose-xattr"].(int); ok {
+		share.ExposeXattr = Itob(exposeXattr)
+	}
+	return share
+}
+
+// Create virtiofs parameters.
+func (c ConfigQemu) CreateQemuVirtiofsParams(params map[string]interface{}) error {
+	for virtiofsID, share := range c.QemuVirtiofs {
+		if share == nil {
+			continue
+		}
+		if virtiofsID < 0 || virtiofsID > 9 {
+			return fmt.Errorf("virtiofs id %d out of range 0-9", virtiofsID)
+		}
+		if share.DirId == "" {
+			return fmt.Errorf("virtiofs%d has no directory mapping", virtiofsID)
+		}
+		if share.Cache != "" && !inArray(virtiofsCacheModes, share.Cache) {
+			return fmt.Errorf("virtiofs cache must be one of %s", strings.Join(virtiofsCacheModes, ", "))
+		}
+		params["virtiofs"+strconv.Itoa(virtiofsID)] = share.String()
+	}
+	return nil
+}
+
+// Create balloon, NUMA and hotplug parameters.
+func (c ConfigQemu) CreateQemuMemoryParams(params map[string]interface{}) error {
+	if c.QemuBalloon < 0 {
+		return errors.New("balloon memory must be positive")
+	}
+	if c.QemuBalloon > 0 {
+		params["balloon"] = c.QemuBalloon
+	}
+	if c.QemuNuma {
+		params["numa"] = true
+	}
+	if c.QemuHotplug != "" {
+		params["hotplug"] = c.QemuHotplug
+	}
+	return nil
+}
+
+// ConfigErrors - all the violations found by ConfigQemu.Validate.
+type ConfigErrors []error
+
+func (errs ConfigErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return "invalid VM config: " + strings.Join(messages, "; ")
+}
+
+// Validate - Check the cross-field constraints of the config before submitting it: OVMF needs an
+// EFI disk, balloon fits in memory, memory hotplug needs NUMA and the boot order only references
+// devices of the config. Values left to their Proxmox default (0) are not range checked.
+// Returns ConfigErrors with every violation, nil when the config is valid.
+func (c ConfigQemu) Validate() error {
+	var errs ConfigErrors
+	if c.Memory > 0 && c.QemuBalloon > c.Memory {
+		errs = append(errs, fmt.Errorf("balloon %dMB exceeds memory %dMB", c.QemuBalloon, c.Memory))
+	}
+	hotplug := hotplugCategories(c.QemuHotplug)
+	if inArray(hotplug, "memory") && !c.QemuNuma {
+		errs = append(errs, errors.New("memory hotplug requires NUMA"))
+	}
+	// Constraints also checked when building the API parameters, OVMF and boot order included.
+	params := map[string]interface{}{}
+	for _, create := range []func(map[string]interface{}) error{
+		c.CreateQemuBootParams,
+		c.CreateQemuExtraDevicesParams,
+		c.CreateQemuMachineParams,
+		c.CreateQemuGuestParams,
+		c.CreateQemuVirtiofsParams,
+		c.CreateQemuMemoryParams,
+		c.CreateQemuScsiHwParams,
+		c.CreateQemuRtcParams,
+	} {
+		if err := create(params); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Create scsihw parameter, iothread on SCSI disks only works with one controller per disk (virtio-scsi-single).
+func (c ConfigQemu) CreateQemuScsiHwParams(params map[string]interface{}) error {
+	err := c.createQemuScsiHwParam(params)
+	if err != nil {
+		return err
+	}
+	return c.checkScsiHwIothread()
+}
+
+func (c ConfigQemu) createQemuScsiHwParam(params map[string]interface{}) error {
+	if c.QemuScsiHw != "" {
+		if !IsValidValue(ScsiHwTypes, c.QemuScsiHw) {
+			return fmt.Errorf("scsihw must be one of %s", strings.Join(ScsiHwTypes, ", "))
+		}
+		params[ConfigKeyScsiHw] = c.QemuScsiHw
+	}
+	return nil
+}
+
+// checkScsiHwIothread - error for the first SCSI disk with iothread on a controller other than virtio-scsi-single.
+func (c ConfigQemu) checkScsiHwIothread() error {
+	if c.QemuScsiHw == ScsiHwVirtioSingle {
+		return nil
+	}
+	for diskID, diskConfMap := range c.QemuDisks {
+		if diskConfMap["type"] != ConfigPrefixScsi {
+			continue
+		}
+		if iothread, _ := toFloat(diskConfMap["iothread"]); iothread != 0 {
+			scsiHw := c.QemuScsiHw
+			if scsiHw == "" {
+				scsiHw = ScsiHwLsi
+			}
+			return fmt.Errorf("iothread on scsi%d needs scsihw %s, not %s", diskID, ScsiHwVirtioSingle, scsiHw)
+		}
+	}
+	return nil
+}
+
+// Create RTC start date parameter, localtime is created with the guest parameters.
+func (c ConfigQemu) CreateQemuRtcParams(params map[string]interface{}) error {
+	if c.QemuStartDate == "" {
+		return nil
+	}
+	if c.QemuStartDate != "now" {
+		_, errDate := time.Parse("2006-01-02", c.QemuStartDate)
+		_, errDateTime := time.Parse("2006-01-02T15:04:05", c.QemuStartDate)
+		if errDate != nil && errDateTime != nil {
+			return fmt.Errorf("invalid startdate '%s', expected now, YYYY-MM-DD or YYYY-MM-DDTHH:MM:SS", c.QemuStartDate)
+		}
+	}
+	params["startdate"] = c.QemuStartDate
+	return nil
+}
+
 // Create the parameters for each device that will be sent to Proxmox API.
 func (p QemuDeviceParam) createDeviceParam(
 	deviceConfMap QemuDevice,
@@ -0,0 +1,57 @@
+package proxmox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConfigQemuValidate(t *testing.T) {
+	disks := QemuDevices{0: {"type": "scsi", "storage": "local-lvm", "size": "8G"}}
+	nics := QemuDevices{0: {"model": "virtio", "bridge": "vmbr0"}}
+	for _, test := range []struct {
+		name   string
+		config ConfigQemu
+		// Substrings of the expected violations, in order.
+		want []string
+	}{
+		{"defaults", ConfigQemu{}, nil},
+		{"valid", ConfigQemu{
+			Memory: 2048, QemuCores: 2, QemuSockets: 1,
+			QemuBios: BiosOvmf, QemuEfiDisk: &QemuEfiDisk{Storage: "local-lvm"},
+			QemuBalloon: 1024, QemuNuma: true, QemuHotplug: "network,disk,memory",
+			QemuDisks: disks, QemuNetworks: nics, BootOrder: []string{"scsi0", "net0"},
+		}, nil},
+		{"balloon without memory", ConfigQemu{QemuBalloon: 512}, nil},
+		{"ovmf without efidisk", ConfigQemu{QemuBios: BiosOvmf}, []string{"requires an efidisk"}},
+		{"balloon over memory", ConfigQemu{Memory: 1024, QemuBalloon: 2048}, []string{"balloon 2048MB exceeds memory 1024MB"}},
+		{"memory hotplug without numa", ConfigQemu{QemuHotplug: "1"}, []string{"memory hotplug requires NUMA"}},
+		{"unknown boot device", ConfigQemu{QemuDisks: disks, BootOrder: []string{"scsi0", "ide2"}}, []string{"boot device 'ide2'"}},
+		{"all violations", ConfigQemu{
+			Memory: 1024, QemuBios: BiosOvmf, QemuBalloon: 4096, QemuHotplug: "memory",
+			BootOrder: []string{"net0"},
+		}, []string{"balloon", "NUMA", "boot device 'net0'", "requires an efidisk"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.Validate()
+			if len(test.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			var errs ConfigErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("err = %v, want ConfigErrors", err)
+			}
+			if len(errs) != len(test.want) {
+				t.Fatalf("got %d violations (%s), want %d", len(errs), err, len(test.want))
+			}
+			for i, want := range test.want {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Errorf("violation %d = %q, want it to mention %q", i, errs[i], want)
+				}
+			}
+		})
+	}
+}
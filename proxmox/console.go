@@ -0,0 +1,74 @@
+package proxmox
+
+import (
+	"fmt"
+	"time"
+)
+
+// SendKeyInterval - pause between keys typed by SendText, slow guests drop keys sent too fast.
+var SendKeyInterval = 20 * time.Millisecond
+
+// QEMU key names of the characters that are not their own key name, on a US keyboard layout.
+var consoleKeys = map[rune]string{
+	' ': "spc", '\n': "ret", '\t': "tab",
+	'-': "minus", '=': "equal", '[': "bracket_left", ']': "bracket_right", '\\': "backslash",
+	';': "semicolon", '\'': "apostrophe", ',': "comma", '.': "dot", '/': "slash", '`': "grave_accent",
+	'!': "shift-1", '@': "shift-2", '#': "shift-3", '$': "shift-4", '%': "shift-5",
+	'^': "shift-6", '&': "shift-7", '*': "shift-8", '(': "shift-9", ')': "shift-0",
+	'_': "shift-minus", '+': "shift-equal", '{': "shift-bracket_left", '}': "shift-bracket_right",
+	'|': "shift-backslash", ':': "shift-semicolon", '"': "shift-apostrophe", '<': "shift-comma",
+	'>': "shift-dot", '?': "shift-slash", '~': "shift-grave_accent",
+}
+
+// KeySequence - QEMU key names typing text on a US keyboard layout.
+func KeySequence(text string) (keys []string, err error) {
+	for _, char := range text {
+		switch {
+		case char >= 'a' && char <= 'z', char >= '0' && char <= '9':
+			keys = append(keys, string(char))
+		case char >= 'A' && char <= 'Z':
+			keys = append(keys, "shift-"+string(char-'A'+'a'))
+		default:
+			key, ok := consoleKeys[char]
+			if !ok {
+				return nil, fmt.Errorf("character %q cannot be typed on the console", char)
+			}
+			keys = append(keys, key)
+		}
+	}
+	return
+}
+
+// SendKey - Press a key combination in the VM console, e.g. `ctrl-alt-delete` or `shift-a`.
+func (c *Client) SendKey(vmr *VmRef, key string) (err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return err
+	}
+	reqbody := ParamsToBody(map[string]interface{}{"key": key})
+	url := ApiPath("nodes", vmr.node, "qemu", vmr.vmId, "sendkey")
+	_, err = c.session.Put(url, nil, nil, &reqbody)
+	return
+}
+
+// SendText - Type text in the VM console, one key at a time. Rescues guests without network nor agent.
+// The guest keyboard layout must be US, characters outside of it are refused before anything is typed.
+func (c *Client) SendText(vmr *VmRef, text string) (err error) {
+	keys, err := KeySequence(text)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		err = c.SendKey(vmr, key)
+		if err != nil {
+			return err
+		}
+		time.Sleep(SendKeyInterval)
+	}
+	return nil
+}
+
+// SendLine - Type line followed by enter in the VM console, like `qm sendline`.
+func (c *Client) SendLine(vmr *VmRef, line string) (err error) {
+	return c.SendText(vmr, line+"\n")
+}
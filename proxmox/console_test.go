@@ -0,0 +1,39 @@
+package proxmox
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeySequence(t *testing.T) {
+	for _, test := range []struct {
+		text    string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"ls", []string{"l", "s"}, false},
+		{"Ab1", []string{"shift-a", "b", "1"}, false},
+		{"a b\n", []string{"a", "spc", "b", "ret"}, false},
+		{"x=1;", []string{"x", "equal", "1", "semicolon"}, false},
+		{"C:\\", []string{"shift-c", "shift-semicolon", "backslash"}, false},
+		{"~_|\"", []string{"shift-grave_accent", "shift-minus", "shift-backslash", "shift-apostrophe"}, false},
+		{"é", nil, true},
+		{"ok€", nil, true},
+	} {
+		got, err := KeySequence(test.text)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("KeySequence(%q): expected an error, got %v", test.text, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("KeySequence(%q): %s", test.text, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("KeySequence(%q) = %v, want %v", test.text, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package proxmox
+
+// Config keys and accepted values of QEMU and LXC guests, to be used instead of raw strings
+// in config params maps: `params[ConfigKeyScsiHw] = ScsiHwVirtioSingle`.
+
+// Config keys shared by QEMU and LXC guests.
+const (
+	ConfigKeyArch         = "arch"
+	ConfigKeyCores        = "cores"
+	ConfigKeyCpuLimit     = "cpulimit"
+	ConfigKeyCpuUnits     = "cpuunits"
+	ConfigKeyDelete       = "delete"
+	ConfigKeyDescription  = "description"
+	ConfigKeyDigest       = "digest"
+	ConfigKeyHookscript   = "hookscript"
+	ConfigKeyLock         = "lock"
+	ConfigKeyMemory       = "memory"
+	ConfigKeyNameserver   = "nameserver"
+	ConfigKeyOnboot       = "onboot"
+	ConfigKeyOsType       = "ostype"
+	ConfigKeyProtection   = "protection"
+	ConfigKeySearchdomain = "searchdomain"
+	ConfigKeyStartup      = "startup"
+	ConfigKeyTags         = "tags"
+	ConfigKeyTemplate     = "template"
+)
+
+// QEMU config keys, numbered devices (net0, scsi0, serial0...) are built with their prefix.
+const (
+	ConfigKeyAgent       = "agent"
+	ConfigKeyArgs        = "args"
+	ConfigKeyAudio       = "audio0"
+	ConfigKeyBalloon     = "balloon"
+	ConfigKeyBios        = "bios"
+	ConfigKeyBoot        = "boot"
+	ConfigKeyCiCustom    = "cicustom"
+	ConfigKeyCiPassword  = "cipassword"
+	ConfigKeyCiUser      = "ciuser"
+	ConfigKeyCpu         = "cpu"
+	ConfigKeyEfiDisk     = "efidisk0"
+	ConfigKeyHotplug     = "hotplug"
+	ConfigKeyKvm         = "kvm"
+	ConfigKeyLocaltime   = "localtime"
+	ConfigKeyMachine     = "machine"
+	ConfigKeyName        = "name"
+	ConfigKeyNuma        = "numa"
+	ConfigKeyRng         = "rng0"
+	ConfigKeyScsiHw      = "scsihw"
+	ConfigKeySockets     = "sockets"
+	ConfigKeySshKeys     = "sshkeys"
+	ConfigKeyTablet      = "tablet"
+	ConfigKeyTpmState    = "tpmstate0"
+	ConfigKeyVcpus       = "vcpus"
+	ConfigKeyVga         = "vga"
+	ConfigPrefixIde      = "ide"
+	ConfigPrefixIpconfig = "ipconfig"
+	ConfigPrefixNet      = "net"
+	ConfigPrefixSata     = "sata"
+	ConfigPrefixScsi     = "scsi"
+	ConfigPrefixSerial   = "serial"
+	ConfigPrefixUnused   = "unused"
+	ConfigPrefixUsb      = "usb"
+	ConfigPrefixVirtio   = "virtio"
+	ConfigPrefixVirtiofs = "virtiofs"
+)
+
+// LXC config keys.
+const (
+	ConfigKeyCmode        = "cmode"
+	ConfigKeyConsole      = "console"
+	ConfigKeyFeatures     = "features"
+	ConfigKeyHostname     = "hostname"
+	ConfigKeyRootfs       = "rootfs"
+	ConfigKeySwap         = "swap"
+	ConfigKeyTty          = "tty"
+	ConfigKeyUnprivileged = "unprivileged"
+	ConfigPrefixMp        = "mp"
+)
+
+// QEMU ostype values.
+const (
+	OsOther   = "other"
+	OsWxp     = "wxp"
+	OsW2k     = "w2k"
+	OsW2k3    = "w2k3"
+	OsW2k8    = "w2k8"
+	OsWvista  = "wvista"
+	OsWin7    = "win7"
+	OsWin8    = "win8"
+	OsWin10   = "win10"
+	OsWin11   = "win11"
+	OsLinux24 = "l24"
+	OsLinux26 = "l26" // Linux 2.6 and later kernels
+	OsSolaris = "solaris"
+)
+
+// QemuOsTypes - accepted QEMU ostype values.
+var QemuOsTypes = []string{OsOther, OsWxp, OsW2k, OsW2k3, OsW2k8, OsWvista, OsWin7, OsWin8, OsWin10, OsWin11, OsLinux24, OsLinux26, OsSolaris}
+
+// LXC ostype values.
+const (
+	LxcOsDebian    = "debian"
+	LxcOsDevuan    = "devuan"
+	LxcOsUbuntu    = "ubuntu"
+	LxcOsCentos    = "centos"
+	LxcOsFedora    = "fedora"
+	LxcOsOpensuse  = "opensuse"
+	LxcOsArchlinux = "archlinux"
+	LxcOsAlpine    = "alpine"
+	LxcOsGentoo    = "gentoo"
+	LxcOsNixos     = "nixos"
+	LxcOsUnmanaged = "unmanaged"
+)
+
+// LxcOsTypes - accepted LXC ostype values.
+var LxcOsTypes = []string{LxcOsDebian, LxcOsDevuan, LxcOsUbuntu, LxcOsCentos, LxcOsFedora, LxcOsOpensuse, LxcOsArchlinux, LxcOsAlpine, LxcOsGentoo, LxcOsNixos, LxcOsUnmanaged}
+
+// SCSI controller (scsihw) values.
+const (
+	ScsiHwLsi          = "lsi"
+	ScsiHwLsi53c810    = "lsi53c810"
+	ScsiHwMegasas      = "megasas"
+	ScsiHwPvscsi       = "pvscsi"
+	ScsiHwVirtio       = "virtio-scsi-pci"
+	ScsiHwVirtioSingle = "virtio-scsi-single"
+)
+
+// ScsiHwTypes - accepted scsihw values.
+var ScsiHwTypes = []string{ScsiHwLsi, ScsiHwLsi53c810, ScsiHwMegasas, ScsiHwPvscsi, ScsiHwVirtio, ScsiHwVirtioSingle}
+
+// Disk cache modes.
+const (
+	CacheNone         = "none"
+	CacheWritethrough = "writethrough"
+	CacheWriteback    = "writeback"
+	CacheUnsafe       = "unsafe"
+	CacheDirectsync   = "directsync"
+)
+
+// DiskCacheModes - accepted disk cache values.
+var DiskCacheModes = []string{CacheNone, CacheWritethrough, CacheWriteback, CacheUnsafe, CacheDirectsync}
+
+// Built-in CPU models, custom models are named `custom-<name>`. The models supported by a node
+// are listed by GetNodeCapabilities.
+const (
+	CpuHost         = "host"
+	CpuMax          = "max"
+	CpuKvm64        = "kvm64"
+	CpuQemu64       = "qemu64"
+	CpuX86_64_v2    = "x86-64-v2"
+	CpuX86_64_v2Aes = "x86-64-v2-AES"
+	CpuX86_64_v3    = "x86-64-v3"
+	CpuX86_64_v4    = "x86-64-v4"
+)
+
+// Guest types of VmRef.
+const (
+	VmTypeQemu = "qemu"
+	VmTypeLxc  = "lxc"
+)
+
+// IsValidValue - is value one of the accepted values, e.g. `IsValidValue(ScsiHwTypes, "virtio-scsi-pci")`.
+func IsValidValue(accepted []string, value string) bool {
+	return inArray(accepted, value)
+}
@@ -0,0 +1,118 @@
+package proxmox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTaskTimeout - a task did not finish within the task timeout, it may still be running.
+type ErrTaskTimeout struct {
+	Upid string
+}
+
+func (e *ErrTaskTimeout) Error() string {
+	return "Wait timeout for:" + e.Upid
+}
+
+// ErrCreationLeftovers - a VM creation failed and what it created could not all be removed.
+// VmExists is set when the VM itself is left (or may be, while its creation task still runs).
+type ErrCreationLeftovers struct {
+	VmId     int
+	Node     string
+	VmExists bool
+	Disks    []string
+	Cause    error
+	Cleanup  []error
+}
+
+func (e *ErrCreationLeftovers) Error() string {
+	var leftovers []string
+	if e.VmExists {
+		leftovers = append(leftovers, fmt.Sprintf("VM %d", e.VmId))
+	}
+	leftovers = append(leftovers, e.Disks...)
+	message := fmt.Sprintf("creation of VM %d on %s failed: %s; left over: %s", e.VmId, e.Node, e.Cause, strings.Join(leftovers, ", "))
+	for _, err := range e.Cleanup {
+		message += "; " + err.Error()
+	}
+	return message
+}
+
+func (e *ErrCreationLeftovers) Unwrap() error {
+	return e.Cause
+}
+
+// vmCreation - artifacts of a VM creation in progress, removed when it fails.
+type vmCreation struct {
+	client *Client
+	node   string
+	vmid   int
+	disks  []string
+	// The create request returned a task, the VM is ours to remove. Before that, a VM with
+	// the same vmid belongs to someone else (e.g. the request was refused as it exists).
+	started bool
+	// The creation may still be running, nothing can be removed safely: its task timed out or
+	// the create request got no answer (see requestMayHaveApplied).
+	taskRunning bool
+}
+
+// requestMayHaveApplied - whether a write failing with err may have been applied anyway: the request
+// failed in transit (connection reset, timeout) without an answer from the API.
+func requestMayHaveApplied(err error) bool {
+	var apiErr *ApiError
+	var circuitErr *ErrCircuitOpen
+	return !errors.As(err, &apiErr) && !errors.As(err, &circuitErr) && !isSafeToRetry(err)
+}
+
+// rollback - remove the VM, when this creation started it, and the disks created for it.
+// Returns nil when everything was removed, ErrCreationLeftovers otherwise.
+func (t *vmCreation) rollback(cause error) error {
+	leftovers := &ErrCreationLeftovers{VmId: t.vmid, Node: t.node, Cause: cause}
+	if t.taskRunning {
+		leftovers.VmExists = true
+		leftovers.Disks = t.disks
+		return leftovers
+	}
+
+	if t.started && t.vmid > 0 {
+		vmr := NewVmRef(t.vmid)
+		vmr.SetNode(t.node)
+		vmr.SetVmType("qemu")
+		_, err := t.client.GetVmState(vmr)
+		if err == nil {
+			// Deleting the VM also deletes the disks attached to it.
+			_, err = t.client.DeleteVm(vmr)
+			if err != nil {
+				leftovers.VmExists = true
+				leftovers.Cleanup = append(leftovers.Cleanup, err)
+			}
+		} else if !IsNotFound(err) {
+			leftovers.VmExists = true
+			leftovers.Cleanup = append(leftovers.Cleanup, err)
+		}
+	}
+
+	for _, disk := range t.disks {
+		if _, err := t.client.GetVolumeInfo(t.node, disk); err != nil {
+			// Already gone with the VM.
+			continue
+		}
+		if _, err := t.client.DeleteVolume(t.node, disk); err != nil {
+			leftovers.Disks = append(leftovers.Disks, disk)
+			leftovers.Cleanup = append(leftovers.Cleanup, err)
+		}
+	}
+	if leftovers.VmExists || len(leftovers.Disks) > 0 {
+		return leftovers
+	}
+	return nil
+}
+
+// fail - rollback after cause, returns the error to report: cause when everything was removed.
+func (t *vmCreation) fail(cause error) error {
+	if err := t.rollback(cause); err != nil {
+		return err
+	}
+	return cause
+}
@@ -0,0 +1,216 @@
+package proxmox
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Proxmox is not consistent about JSON types: depending on the release and endpoint, numeric
+// fields come as numbers, as strings or are missing. The helpers below read such fields
+// without panicking and report which field could not be decoded.
+
+// FlexInt - integer decoded from a JSON number, a numeric string, or null/missing (zero).
+type FlexInt int64
+
+func (i *FlexInt) UnmarshalJSON(data []byte) error {
+	value, err := parseFlexNumber(data)
+	if err != nil {
+		return err
+	}
+	*i = FlexInt(value)
+	return nil
+}
+
+// FlexFloat - float decoded from a JSON number, a numeric string, or null/missing (zero).
+type FlexFloat float64
+
+func (f *FlexFloat) UnmarshalJSON(data []byte) error {
+	value, err := parseFlexNumber(data)
+	if err != nil {
+		return err
+	}
+	*f = FlexFloat(value)
+	return nil
+}
+
+// FlexBool - boolean decoded from true/false, 0/1 numbers or strings.
+type FlexBool bool
+
+func (b *FlexBool) UnmarshalJSON(data []byte) error {
+	raw := strings.Trim(string(data), `"`)
+	switch raw {
+	case "true", "1":
+		*b = true
+	case "false", "0", "", "null":
+		*b = false
+	default:
+		return fmt.Errorf("cannot decode %s as boolean", data)
+	}
+	return nil
+}
+
+func parseFlexNumber(data []byte) (float64, error) {
+	raw := strings.Trim(string(data), `"`)
+	if raw == "" || raw == "null" {
+		return 0, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot decode %s as number", data)
+	}
+	return value, nil
+}
+
+// toFloat - numeric value of a decoded JSON field, whatever its representation.
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// GetFloat - read a numeric field, returns an error when it is missing or not numeric.
+func GetFloat(m map[string]interface{}, key string) (float64, error) {
+	value, isSet := m[key]
+	if !isSet || value == nil {
+		return 0, fmt.Errorf("field '%s' is missing", key)
+	}
+	f, ok := toFloat(value)
+	if !ok {
+		return 0, fmt.Errorf("field '%s' is not numeric: %v", key, value)
+	}
+	return f, nil
+}
+
+// GetInt - read an integer field, returns an error when it is missing or not numeric.
+func GetInt(m map[string]interface{}, key string) (int, error) {
+	f, err := GetFloat(m, key)
+	return int(f), err
+}
+
+// GetIntDefault - read an integer field, defaultValue is used when it is missing or not numeric.
+func GetIntDefault(m map[string]interface{}, key string, defaultValue int) int {
+	i, err := GetInt(m, key)
+	if err != nil {
+		return defaultValue
+	}
+	return i
+}
+
+// GetFloatDefault - read a numeric field, defaultValue is used when it is missing or not numeric.
+func GetFloatDefault(m map[string]interface{}, key string, defaultValue float64) float64 {
+	f, err := GetFloat(m, key)
+	if err != nil {
+		return defaultValue
+	}
+	return f
+}
+
+// GetString - read a string field, numbers are formatted, missing fields are empty.
+func GetString(m map[string]interface{}, key string) string {
+	switch v := m[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// VmResource - guest entry of /cluster/resources?type=vm.
+type VmResource struct {
+	Id       string    `json:"id"`
+	VmId     FlexInt   `json:"vmid"`
+	Name     string    `json:"name"`
+	Node     string    `json:"node"`
+	Type     string    `json:"type"`
+	Status   string    `json:"status"`
+	Pool     string    `json:"pool"`
+	Tags     string    `json:"tags"`
+	Template FlexBool  `json:"template"`
+	Cpu      FlexFloat `json:"cpu"`
+	MaxCpu   FlexInt   `json:"maxcpu"`
+	Mem      FlexInt   `json:"mem"`
+	MaxMem   FlexInt   `json:"maxmem"`
+	Disk     FlexInt   `json:"disk"`
+	MaxDisk  FlexInt   `json:"maxdisk"`
+	Uptime   FlexInt   `json:"uptime"`
+}
+
+// GetVmResources - typed listing of all guests of the cluster.
+func (c *Client) GetVmResources() (vms []VmResource, err error) {
+	return getApiData[[]VmResource](c, "/cluster/resources?type=vm")
+}
+
+// VmResourceList - guests of the cluster with the nodes that could not report theirs.
+// When Partial is set, guests of UnreachableNodes may be missing or stale (status `unknown`),
+// a guest missing from Vms is not necessarily deleted.
+type VmResourceList struct {
+	Vms              []VmResource
+	Partial          bool
+	UnreachableNodes []string
+}
+
+// GetVmResourceList - listing of all guests of the cluster, flagging nodes that are offline.
+func (c *Client) GetVmResourceList() (list *VmResourceList, err error) {
+	resources, err := getApiData[[]VmResource](c, "/cluster/resources")
+	if err != nil {
+		return nil, err
+	}
+	list = &VmResourceList{}
+	for _, resource := range resources {
+		switch resource.Type {
+		case "qemu", "lxc":
+			list.Vms = append(list.Vms, resource)
+		case "node":
+			if resource.Status != "online" {
+				list.UnreachableNodes = append(list.UnreachableNodes, resource.Node)
+			}
+		}
+	}
+	list.Partial = len(list.UnreachableNodes) > 0
+	return
+}
+
+// NodeInfo - node entry of /nodes, Cpu is the utilization between 0 and 1 and Level the
+// support subscription level (empty without subscription).
+type NodeInfo struct {
+	Node           string    `json:"node"`
+	Status         string    `json:"status"`
+	Cpu            FlexFloat `json:"cpu"`
+	MaxCpu         FlexInt   `json:"maxcpu"`
+	Mem            FlexInt   `json:"mem"`
+	MaxMem         FlexInt   `json:"maxmem"`
+	Disk           FlexInt   `json:"disk"`
+	MaxDisk        FlexInt   `json:"maxdisk"`
+	Uptime         FlexInt   `json:"uptime"`
+	Level          string    `json:"level"`
+	SslFingerprint string    `json:"ssl_fingerprint"`
+}
+
+// NodeFilter - which nodes GetNodes returns, NameGlob is a shell pattern like `pve-*`.
+type NodeFilter struct {
+	OnlineOnly bool
+	NameGlob   string
+}
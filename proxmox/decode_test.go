@@ -0,0 +1,49 @@
+package proxmox
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFlexDecoding(t *testing.T) {
+	type flexFields struct {
+		Int   FlexInt   `json:"int"`
+		Float FlexFloat `json:"float"`
+		Bool  FlexBool  `json:"bool"`
+	}
+	for _, test := range []struct {
+		json    string
+		want    flexFields
+		wantErr bool
+	}{
+		{`{}`, flexFields{}, false},
+		{`{"int":null,"float":null,"bool":null}`, flexFields{}, false},
+		{`{"int":42,"float":0.25,"bool":true}`, flexFields{42, 0.25, true}, false},
+		{`{"int":"42","float":"0.25","bool":"1"}`, flexFields{42, 0.25, true}, false},
+		{`{"int":"","float":"","bool":""}`, flexFields{}, false},
+		{`{"bool":1}`, flexFields{Bool: true}, false},
+		{`{"bool":"0"}`, flexFields{}, false},
+		{`{"bool":false}`, flexFields{}, false},
+		{`{"int":1e3}`, flexFields{Int: 1000}, false},
+		{`{"int":"many"}`, flexFields{}, true},
+		{`{"float":"n/a"}`, flexFields{}, true},
+		{`{"bool":"yes"}`, flexFields{}, true},
+		{`{"bool":2}`, flexFields{}, true},
+	} {
+		var got flexFields
+		err := json.Unmarshal([]byte(test.json), &got)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %+v", test.json, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.json, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.json, got, test.want)
+		}
+	}
+}
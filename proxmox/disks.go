@@ -0,0 +1,68 @@
+package proxmox
+
+import (
+	"fmt"
+)
+
+// NodeDisk - physical disk of a node, Used tells what it holds (LVM, ZFS, partitions, mounted...)
+// and is empty for unused disks.
+type NodeDisk struct {
+	DevPath string   `json:"devpath"`
+	Model   string   `json:"model"`
+	Serial  string   `json:"serial"`
+	Wwn     string   `json:"wwn"`
+	Type    string   `json:"type"`
+	Size    FlexInt  `json:"size"`
+	Used    string   `json:"used"`
+	Health  string   `json:"health"`
+	Gpt     FlexBool `json:"gpt"`
+}
+
+// ListNodeDisks - List the physical disks of node.
+func (c *Client) ListNodeDisks(node string) (disks []NodeDisk, err error) {
+	return getApiData[[]NodeDisk](c, ApiPath("nodes", node, "disks", "list"))
+}
+
+// ErrWipeNotConfirmed - WipeDisk was not given the identity of the disk it is about to wipe.
+type ErrWipeNotConfirmed struct {
+	Node string
+	Disk NodeDisk
+}
+
+func (e *ErrWipeNotConfirmed) Error() string {
+	return fmt.Sprintf("wiping %s on %s (%s, serial '%s') needs its serial as confirmation", e.Disk.DevPath, e.Node, e.Disk.Model, e.Disk.Serial)
+}
+
+// WipeDisk - Wipe the partition table and signatures of device (`/dev/sdb`) on node so ZFS or LVM
+// can be created on it (PVE 7+). Device names can change across reboots, confirm must be the serial
+// of the disk (its device path for disks without serial), read with ListNodeDisks, or nothing is
+// done. Proxmox refuses to wipe disks that are mounted or in use.
+func (c *Client) WipeDisk(node string, device string, confirm string) (exitStatus string, err error) {
+	disks, err := c.ListNodeDisks(node)
+	if err != nil {
+		return "", err
+	}
+	var disk *NodeDisk
+	for ii := range disks {
+		if disks[ii].DevPath == device {
+			disk = &disks[ii]
+		}
+	}
+	if disk == nil {
+		return "", &ErrNotFound{fmt.Sprintf("disk '%s' not found on node '%s'", device, node)}
+	}
+	expected := disk.Serial
+	if expected == "" {
+		expected = disk.DevPath
+	}
+	if confirm != expected {
+		return "", &ErrWipeNotConfirmed{Node: node, Disk: *disk}
+	}
+	reqbody := ParamsToBody(map[string]interface{}{"disk": device})
+	resp, err := c.session.Put(ApiPath("nodes", node, "disks", "wipedisk"), nil, nil, &reqbody)
+	if err == nil {
+		taskResponse := ResponseJSON(resp)
+		exitStatus, err = c.WaitForCompletion(taskResponse)
+	}
+	return
+}
@@ -0,0 +1,77 @@
+package proxmox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ApiResponse - envelope of Proxmox API responses. Data holds the payload, Errors the
+// per-parameter validation errors, Total the collection size of paginated endpoints.
+type ApiResponse[T any] struct {
+	Data    T                 `json:"data"`
+	Errors  map[string]string `json:"errors"`
+	Success *FlexBool         `json:"success"`
+	Message string            `json:"message"`
+	Total   *FlexInt          `json:"total"`
+}
+
+// Err - error reported by the envelope, nil when the call succeeded.
+func (r ApiResponse[T]) Err() error {
+	if len(r.Errors) == 0 && (r.Success == nil || *r.Success) {
+		return nil
+	}
+	message := r.Message
+	if message == "" {
+		message = "request failed"
+	}
+	return &ApiError{Code: 200, Message: message, Errors: r.Errors}
+}
+
+// formatApiErrors - `param: message` pairs sorted by parameter.
+func formatApiErrors(apiErrors map[string]string) string {
+	var details []string
+	for param, message := range apiErrors {
+		details = append(details, fmt.Sprintf("%s: %s", param, strings.TrimSpace(message)))
+	}
+	sort.Strings(details)
+	return strings.Join(details, ", ")
+}
+
+// readApiErrors - validation errors from the body of a failed response, nil when there are none.
+func readApiErrors(body io.Reader) map[string]string {
+	var envelope ApiResponse[json.RawMessage]
+	// Error bodies are small, bound the read in case a proxy answers with a page.
+	err := json.NewDecoder(io.LimitReader(body, 64*1024)).Decode(&envelope)
+	if err != nil {
+		return nil
+	}
+	return envelope.Errors
+}
+
+// getApiData - GET url with retries and decode its envelope, returning Data.
+func getApiData[T any](c *Client, url string) (data T, err error) {
+	var response ApiResponse[T]
+	err = c.GetJsonRetryable(url, &response, 3)
+	if err != nil {
+		return data, err
+	}
+	return response.Data, response.Err()
+}
+
+// getApiDataWithParams - GET path with query params and decode its envelope, returning Data.
+func getApiDataWithParams[T any](c *Client, path string, params url.Values) (data T, err error) {
+	var response ApiResponse[T]
+	var query *url.Values
+	if len(params) > 0 {
+		query = &params
+	}
+	_, err = c.session.GetJSON(path, query, nil, &response)
+	if err != nil {
+		return data, err
+	}
+	return response.Data, response.Err()
+}
@@ -0,0 +1,72 @@
+package proxmox
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+)
+
+// ErrNotFound - a guest or other object looked up by the library does not exist.
+type ErrNotFound struct {
+	Message string
+}
+
+func (e *ErrNotFound) Error() string {
+	return e.Message
+}
+
+// Messages of the 500 errors Proxmox answers for unknown guests (their config file), storages,
+// volumes, pools and users: `Configuration file 'nodes/pve/qemu-server/100.conf' does not exist`.
+var rxNotFoundMessage = regexp.MustCompile(`(?:Configuration file|storage|volume|pool|user|group|role) '[^']*' does not exist`)
+
+// IsNotFound - is err caused by a missing object? Proxmox answers 404 for unknown paths and 500 with
+// `... does not exist` for unknown guests, storages or volumes (see rxNotFoundMessage).
+func IsNotFound(err error) bool {
+	var notFoundErr *ErrNotFound
+	if errors.As(err, &notFoundErr) {
+		return true
+	}
+	var apiErr *ApiError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == http.StatusNotFound ||
+		(apiErr.Code == http.StatusInternalServerError && rxNotFoundMessage.MatchString(apiErr.Message))
+}
+
+// IsPermissionDenied - is err caused by missing privileges or a rejected authentication?
+func IsPermissionDenied(err error) bool {
+	var privilegesErr *ErrMissingPrivileges
+	if errors.As(err, &privilegesErr) {
+		return true
+	}
+	var apiErr *ApiError
+	return errors.As(err, &apiErr) && (apiErr.Code == http.StatusForbidden || apiErr.Code == http.StatusUnauthorized)
+}
+
+// IsRetryable - can the operation failing with err be tried again later with a chance of success?
+// True for connection failures and timeouts, unavailable nodes (502, 503, 504, 595, 596), rate
+// limiting, open circuit breakers, offline nodes, guest locks, lock file contention and tasks
+// still running. Validation, permission and not found errors are permanent.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if isSafeToRetry(err) || IsVmLocked(err) || IsTransient(err) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var circuitErr *ErrCircuitOpen
+	var timeoutErr *ErrTaskTimeout
+	var offlineErr *ErrNodeOffline
+	if errors.As(err, &circuitErr) || errors.As(err, &timeoutErr) || errors.As(err, &offlineErr) {
+		return true
+	}
+	var apiErr *ApiError
+	if errors.As(err, &apiErr) {
+		return isUnavailableStatus(apiErr.Code)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
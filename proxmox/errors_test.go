@@ -0,0 +1,32 @@
+package proxmox
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestIsNotFound(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{&ErrNotFound{"Vm '100' not found"}, true},
+		{fmt.Errorf("reading config: %w", &ErrNotFound{"Vm '100' not found"}), true},
+		{&ApiError{Code: http.StatusNotFound, Message: "Not Found"}, true},
+		{&ApiError{Code: http.StatusInternalServerError, Message: "Configuration file 'nodes/pve/qemu-server/100.conf' does not exist"}, true},
+		{&ApiError{Code: http.StatusInternalServerError, Message: "storage 'backup' does not exist"}, true},
+		{&ApiError{Code: http.StatusInternalServerError, Message: "volume 'local:iso/debian.iso' does not exist"}, true},
+		{&ApiError{Code: http.StatusInternalServerError, Message: "mkdir /mnt/pve/nfs/images: no such file or directory"}, false},
+		{&ApiError{Code: http.StatusInternalServerError, Message: "rbd error: No such device"}, false},
+		{&ApiError{Code: http.StatusInternalServerError, Message: "command 'zfs list' failed: dataset does not exist"}, false},
+		{&ApiError{Code: http.StatusBadRequest, Message: "storage 'backup' does not exist"}, false},
+		{errors.New("storage 'backup' does not exist"), false},
+	} {
+		if got := IsNotFound(test.err); got != test.want {
+			t.Errorf("IsNotFound(%v) = %t, want %t", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package proxmox
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// NodeCommand - API call run by /nodes/{node}/execute, Path is relative to the API root
+// (e.g. `/nodes/pve1/qemu/100/status/current`).
+type NodeCommand struct {
+	Method string                 `json:"method"`
+	Path   string                 `json:"path"`
+	Args   map[string]interface{} `json:"args,omitempty"`
+}
+
+// NodeCommandResult - response to one NodeCommand.
+type NodeCommandResult struct {
+	Status  int               `json:"status"`
+	Data    json.RawMessage   `json:"data"`
+	Message string            `json:"message"`
+	Errors  map[string]string `json:"errors"`
+}
+
+// Err - error of the command, nil when it succeeded.
+func (r NodeCommandResult) Err() error {
+	if r.Status >= 200 && r.Status <= 299 {
+		return nil
+	}
+	return &ApiError{Code: r.Status, Message: strings.TrimSpace(r.Message), Errors: r.Errors}
+}
+
+// Decode - unmarshal the command data into v.
+func (r NodeCommandResult) Decode(v interface{}) error {
+	err := r.Err()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(r.Data, v)
+}
+
+// NodeBatch - commands composed client side then run in a single request to a node.
+type NodeBatch struct {
+	Node     string
+	Commands []NodeCommand
+}
+
+// NewNodeBatch - empty batch of commands to run on node.
+func NewNodeBatch(node string) *NodeBatch {
+	return &NodeBatch{Node: node}
+}
+
+// Add - append a command, args are the call parameters.
+func (b *NodeBatch) Add(method string, path string, args map[string]interface{}) *NodeBatch {
+	b.Commands = append(b.Commands, NodeCommand{Method: method, Path: path, Args: args})
+	return b
+}
+
+// Get - append a GET command.
+func (b *NodeBatch) Get(path string) *NodeBatch {
+	return b.Add(http.MethodGet, path, nil)
+}
+
+// Post - append a POST command.
+func (b *NodeBatch) Post(path string, args map[string]interface{}) *NodeBatch {
+	return b.Add(http.MethodPost, path, args)
+}
+
+// Put - append a PUT command.
+func (b *NodeBatch) Put(path string, args map[string]interface{}) *NodeBatch {
+	return b.Add(http.MethodPut, path, args)
+}
+
+// Delete - append a DELETE command.
+func (b *NodeBatch) Delete(path string) *NodeBatch {
+	return b.Add(http.MethodDelete, path, nil)
+}
+
+// NodeExecute - Run commands sequentially on node in one request (/nodes/{node}/execute, root only).
+// Results are in the order of commands, a failed command does not stop the following ones.
+func (c *Client) NodeExecute(node string, commands []NodeCommand) (results []NodeCommandResult, err error) {
+	if len(commands) == 0 {
+		return nil, nil
+	}
+	commandsJson, err := json.Marshal(commands)
+	if err != nil {
+		return nil, err
+	}
+	reqbody := ParamsToBody(map[string]interface{}{"commands": string(commandsJson)})
+	resp, err := c.session.Post(ApiPath("nodes", node, "execute"), nil, nil, &reqbody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var response ApiResponse[[]NodeCommandResult]
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Data) != len(commands) {
+		return nil, &ErrUnexpectedResponse{fmt.Sprintf("%d results for %d commands", len(response.Data), len(commands)), response.Data}
+	}
+	return response.Data, response.Err()
+}
+
+// ExecuteNodeBatch - Run the batch, see NodeExecute.
+func (c *Client) ExecuteNodeBatch(batch *NodeBatch) (results []NodeCommandResult, err error) {
+	return c.NodeExecute(batch.Node, batch.Commands)
+}
@@ -0,0 +1,36 @@
+package proxmox
+
+import (
+	"net/url"
+)
+
+// FirewallMacro - predefined firewall rule macro (SSH, HTTP, Ceph...).
+type FirewallMacro struct {
+	Macro       string `json:"macro"`
+	Description string `json:"descr"`
+}
+
+// FirewallRef - alias or IP set usable as source or destination of firewall rules.
+// Ref is the value to put in rules, e.g. `+dc/management` for an IP set.
+type FirewallRef struct {
+	Type    string `json:"type"` // alias or ipset
+	Name    string `json:"name"`
+	Ref     string `json:"ref"`
+	Scope   string `json:"scope"` // dc or sdn
+	Comment string `json:"comment"`
+}
+
+// GetFirewallMacros - List the firewall rule macros.
+func (c *Client) GetFirewallMacros() (macros []FirewallMacro, err error) {
+	return getApiData[[]FirewallMacro](c, "/cluster/firewall/macros")
+}
+
+// GetFirewallRefs - List the cluster aliases and IP sets usable in rules, refType filters on
+// `alias` or `ipset` when not empty.
+func (c *Client) GetFirewallRefs(refType string) (refs []FirewallRef, err error) {
+	params := url.Values{}
+	if refType != "" {
+		params.Set("type", refType)
+	}
+	return getApiDataWithParams[[]FirewallRef](c, "/cluster/firewall/refs", params)
+}
@@ -0,0 +1,587 @@
+package proxmox
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Firewall configurations as declarative documents: ExportFirewall reads the rules, options,
+// aliases and IP sets of a firewall into a FirewallDocument (stable JSON, no positions nor
+// digests) and ApplyFirewall makes a firewall match a document with rule-level changes.
+// Security groups are not part of the document.
+
+// FirewallRule - rule of a firewall, in the order it is evaluated. Type is in, out or group
+// (Action is then the security group name).
+type FirewallRule struct {
+	Type     string `json:"type"`
+	Action   string `json:"action"`
+	Enable   bool   `json:"enable"`
+	Macro    string `json:"macro,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Dest     string `json:"dest,omitempty"`
+	Proto    string `json:"proto,omitempty"`
+	Sport    string `json:"sport,omitempty"`
+	Dport    string `json:"dport,omitempty"`
+	IcmpType string `json:"icmp-type,omitempty"`
+	Iface    string `json:"iface,omitempty"`
+	Log      string `json:"log,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// FirewallAlias - named address or network.
+type FirewallAlias struct {
+	Name    string `json:"name"`
+	Cidr    string `json:"cidr"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// FirewallIpSetEntry - member of an IP set, NoMatch excludes it from the set.
+type FirewallIpSetEntry struct {
+	Cidr    string `json:"cidr"`
+	NoMatch bool   `json:"nomatch,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// FirewallIpSet - named set of addresses and networks.
+type FirewallIpSet struct {
+	Name    string               `json:"name"`
+	Comment string               `json:"comment,omitempty"`
+	Entries []FirewallIpSetEntry `json:"entries"`
+}
+
+// FirewallDocument - normalized firewall configuration. Aliases and IP sets are sorted by name,
+// nodes have neither. Options only lists the options to manage, others are left untouched.
+type FirewallDocument struct {
+	Options map[string]interface{} `json:"options,omitempty"`
+	Rules   []FirewallRule         `json:"rules"`
+	Aliases []FirewallAlias        `json:"aliases,omitempty"`
+	IpSets  []FirewallIpSet        `json:"ipsets,omitempty"`
+}
+
+// FirewallChange - change made, or to make, by ApplyFirewall. Op is add, update, move or delete,
+// Kind is rule, option, alias, ipset or ipset-entry and Name identifies it (rule position, option,
+// alias name, IP set name or `set/cidr`).
+type FirewallChange struct {
+	Op   string
+	Kind string
+	Name string
+}
+
+func (change FirewallChange) String() string {
+	return fmt.Sprintf("%s %s %s", change.Op, change.Kind, change.Name)
+}
+
+// ClusterFirewallPath - API path of the cluster firewall.
+func ClusterFirewallPath() string {
+	return "/cluster/firewall"
+}
+
+// NodeFirewallPath - API path of the firewall of node.
+func NodeFirewallPath(node string) string {
+	return ApiPath("nodes", node, "firewall")
+}
+
+// VmFirewallPath - API path of the firewall of a guest.
+func (c *Client) VmFirewallPath(vmr *VmRef) (path string, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return "", err
+	}
+	return ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "firewall"), nil
+}
+
+// isNodeFirewall - node firewalls have no aliases nor IP sets.
+func isNodeFirewall(path string) bool {
+	return strings.HasPrefix(path, "/nodes/") && strings.Count(path, "/") == 3
+}
+
+// ParseFirewallDocument - read a document written by FirewallDocument.Json.
+func ParseFirewallDocument(data []byte) (doc *FirewallDocument, err error) {
+	doc = &FirewallDocument{}
+	err = json.Unmarshal(data, doc)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Json - indented JSON of the document, stable for a given configuration.
+func (doc FirewallDocument) Json() ([]byte, error) {
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// firewallRuleFromApi - rule from a rules listing entry.
+func firewallRuleFromApi(entry map[string]interface{}) FirewallRule {
+	return FirewallRule{
+		Type:     GetString(entry, "type"),
+		Action:   GetString(entry, "action"),
+		Enable:   GetIntDefault(entry, "enable", 0) == 1,
+		Macro:    GetString(entry, "macro"),
+		Source:   GetString(entry, "source"),
+		Dest:     GetString(entry, "dest"),
+		Proto:    GetString(entry, "proto"),
+		Sport:    GetString(entry, "sport"),
+		Dport:    GetString(entry, "dport"),
+		IcmpType: GetString(entry, "icmp-type"),
+		Iface:    GetString(entry, "iface"),
+		Log:      GetString(entry, "log"),
+		Comment:  GetString(entry, "comment"),
+	}
+}
+
+// params - rule parameters, empty fields are listed in delete so updates clear them.
+func (rule FirewallRule) params() map[string]interface{} {
+	params := map[string]interface{}{
+		"type":   rule.Type,
+		"action": rule.Action,
+		"enable": rule.Enable,
+	}
+	var deletes []string
+	for _, field := range [][2]string{
+		{"macro", rule.Macro},
+		{"source", rule.Source},
+		{"dest", rule.Dest},
+		{"proto", rule.Proto},
+		{"sport", rule.Sport},
+		{"dport", rule.Dport},
+		{"icmp-type", rule.IcmpType},
+		{"iface", rule.Iface},
+		{"log", rule.Log},
+		{"comment", rule.Comment},
+	} {
+		if field[1] != "" {
+			params[field[0]] = field[1]
+		} else {
+			deletes = append(deletes, field[0])
+		}
+	}
+	if len(deletes) > 0 {
+		params["delete"] = strings.Join(deletes, ",")
+	}
+	return params
+}
+
+// firewallDigests - digests of the lists of a firewall (options, rules, aliases, IP sets and the
+// entries of each), keyed by list path. Writes carry the digest of their list so that changes made
+// since it was read fail instead of being overwritten.
+type firewallDigests map[string]string
+
+// listDigest - digest of a listing, repeated in each of its entries.
+func listDigest(entries []map[string]interface{}) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	return GetString(entries[0], "digest")
+}
+
+// ExportFirewall - Read the firewall at path (ClusterFirewallPath, NodeFirewallPath or VmFirewallPath)
+// as a document.
+func (c *Client) ExportFirewall(path string) (doc *FirewallDocument, err error) {
+	doc, _, err = c.exportFirewall(path)
+	return
+}
+
+func (c *Client) exportFirewall(path string) (doc *FirewallDocument, digests firewallDigests, err error) {
+	doc = &FirewallDocument{}
+	digests = firewallDigests{}
+	doc.Options, err = getApiData[map[string]interface{}](c, path+"/options")
+	if err != nil {
+		return nil, nil, err
+	}
+	digests[path+"/options"] = GetString(doc.Options, "digest")
+	delete(doc.Options, "digest")
+	entries, err := getApiData[[]map[string]interface{}](c, path+"/rules")
+	if err != nil {
+		return nil, nil, err
+	}
+	digests[path+"/rules"] = listDigest(entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return GetIntDefault(entries[i], "pos", 0) < GetIntDefault(entries[j], "pos", 0)
+	})
+	doc.Rules = []FirewallRule{}
+	for _, entry := range entries {
+		doc.Rules = append(doc.Rules, firewallRuleFromApi(entry))
+	}
+	if isNodeFirewall(path) {
+		return
+	}
+
+	entries, err = getApiData[[]map[string]interface{}](c, path+"/aliases")
+	if err != nil {
+		return nil, nil, err
+	}
+	digests[path+"/aliases"] = listDigest(entries)
+	for _, entry := range entries {
+		doc.Aliases = append(doc.Aliases, FirewallAlias{
+			Name:    GetString(entry, "name"),
+			Cidr:    GetString(entry, "cidr"),
+			Comment: GetString(entry, "comment"),
+		})
+	}
+	sort.Slice(doc.Aliases, func(i, j int) bool { return doc.Aliases[i].Name < doc.Aliases[j].Name })
+	entries, err = getApiData[[]map[string]interface{}](c, path+"/ipset")
+	if err != nil {
+		return nil, nil, err
+	}
+	digests[path+"/ipset"] = listDigest(entries)
+	for _, entry := range entries {
+		doc.IpSets = append(doc.IpSets, FirewallIpSet{
+			Name:    GetString(entry, "name"),
+			Comment: GetString(entry, "comment"),
+		})
+	}
+	sort.Slice(doc.IpSets, func(i, j int) bool { return doc.IpSets[i].Name < doc.IpSets[j].Name })
+	for ii := range doc.IpSets {
+		setPath := path + ApiPath("ipset", doc.IpSets[ii].Name)
+		doc.IpSets[ii].Entries, digests[setPath], err = c.getFirewallIpSetEntries(setPath)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return
+}
+
+func (c *Client) getFirewallIpSetEntries(setPath string) (entries []FirewallIpSetEntry, digest string, err error) {
+	list, err := getApiData[[]map[string]interface{}](c, setPath)
+	if err != nil {
+		return nil, "", err
+	}
+	entries = []FirewallIpSetEntry{}
+	for _, entry := range list {
+		entries = append(entries, FirewallIpSetEntry{
+			Cidr:    GetString(entry, "cidr"),
+			NoMatch: GetIntDefault(entry, "nomatch", 0) == 1,
+			Comment: GetString(entry, "comment"),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Cidr < entries[j].Cidr })
+	return entries, listDigest(list), nil
+}
+
+// write - Send a change to item of the list at listPath (item is empty for the list itself) with
+// the digest of the list, then read back the new digest of the list for the next change.
+func (digests firewallDigests) write(c *Client, method string, listPath string, item string, params map[string]interface{}) (err error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	if digest := digests[listPath]; digest != "" {
+		params["digest"] = digest
+	}
+	switch method {
+	case "POST":
+		reqbody := ParamsToBody(params)
+		_, err = c.session.Post(listPath+item, nil, nil, &reqbody)
+	case "PUT":
+		reqbody := ParamsToBody(params)
+		_, err = c.session.Put(listPath+item, nil, nil, &reqbody)
+	case "DELETE":
+		query := url.Values{}
+		for key, value := range params {
+			query.Set(key, fmt.Sprint(value))
+		}
+		_, err = c.session.Delete(listPath+item, &query, nil)
+	}
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(listPath, "/options") {
+		options, err := getApiData[map[string]interface{}](c, listPath)
+		digests[listPath] = GetString(options, "digest")
+		return err
+	}
+	entries, err := getApiData[[]map[string]interface{}](c, listPath)
+	digests[listPath] = listDigest(entries)
+	return err
+}
+
+// firewallOptionValue - option value as compared by ApplyFirewall, booleans as the 1 and 0 returned
+// by the API.
+func firewallOptionValue(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		if value {
+			return "1"
+		}
+		return "0"
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// FirewallRuleOp - rule change computed by DiffFirewallRules, to apply in order. Op is add, move or
+// delete, Pos the position of the rule when the change is made, MoveTo the position a moved rule
+// goes to and Rule the added rule.
+type FirewallRuleOp struct {
+	Op     string
+	Pos    int
+	MoveTo int
+	Rule   FirewallRule
+}
+
+func (op FirewallRuleOp) change() FirewallChange {
+	if op.Op == "move" {
+		return FirewallChange{Op: op.Op, Kind: "rule", Name: fmt.Sprintf("%d to %d", op.Pos, op.MoveTo)}
+	}
+	return FirewallChange{Op: op.Op, Kind: "rule", Name: fmt.Sprint(op.Pos)}
+}
+
+// DiffFirewallRules - rule changes turning current into desired. Rules are matched by identity:
+// current rules absent from desired are deleted, last first, and desired rules are then put in
+// place first to last, moving up the matching current rule or adding the rule when none matches.
+// Rules have no identifier, a rule whose fields change is deleted and added.
+func DiffFirewallRules(current []FirewallRule, desired []FirewallRule) (ops []FirewallRuleOp) {
+	matched := make([]int, len(desired))
+	used := make([]bool, len(current))
+	for pos, rule := range desired {
+		matched[pos] = -1
+		for cur := range current {
+			if !used[cur] && current[cur] == rule {
+				used[cur] = true
+				matched[pos] = cur
+				break
+			}
+		}
+	}
+	// Current index of each remaining rule, -1 for added ones.
+	var remaining []int
+	for cur := len(current) - 1; cur >= 0; cur-- {
+		if !used[cur] {
+			ops = append(ops, FirewallRuleOp{Op: "delete", Pos: cur})
+		}
+	}
+	for cur := range current {
+		if used[cur] {
+			remaining = append(remaining, cur)
+		}
+	}
+	for pos, rule := range desired {
+		if matched[pos] < 0 {
+			ops = append(ops, FirewallRuleOp{Op: "add", Pos: pos, Rule: rule})
+			remaining = append(remaining[:pos], append([]int{-1}, remaining[pos:]...)...)
+			continue
+		}
+		at := pos
+		for remaining[at] != matched[pos] {
+			at++
+		}
+		if at != pos {
+			ops = append(ops, FirewallRuleOp{Op: "move", Pos: at, MoveTo: pos})
+			copy(remaining[pos+1:at+1], remaining[pos:at])
+			remaining[pos] = matched[pos]
+		}
+	}
+	return
+}
+
+// ApplyFirewall - Make the firewall at path match doc and return the changes, only computed when
+// dryRun is set. Rules are matched by identity (see DiffFirewallRules), aliases and IP sets by name,
+// options only for the keys of doc.Options. Every change is checked against the digest of its list,
+// so the firewall must not be changed concurrently.
+func (c *Client) ApplyFirewall(path string, doc FirewallDocument, dryRun bool) (changes []FirewallChange, err error) {
+	current, digests, err := c.exportFirewall(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Options.
+	options := map[string]interface{}{}
+	for key, value := range doc.Options {
+		if firewallOptionValue(current.Options[key]) != firewallOptionValue(value) {
+			options[key] = value
+			changes = append(changes, FirewallChange{Op: "update", Kind: "option", Name: key})
+		}
+	}
+	if len(options) > 0 && !dryRun {
+		err = digests.write(c, "PUT", path+"/options", "", options)
+		if err != nil {
+			return changes, err
+		}
+	}
+
+	rulesPath := path + "/rules"
+	for _, op := range DiffFirewallRules(current.Rules, doc.Rules) {
+		changes = append(changes, op.change())
+		if dryRun {
+			continue
+		}
+		switch op.Op {
+		case "delete":
+			err = digests.write(c, "DELETE", rulesPath, ApiPath(op.Pos), nil)
+		case "move":
+			err = digests.write(c, "PUT", rulesPath, ApiPath(op.Pos), map[string]interface{}{"moveto": op.MoveTo})
+		case "add":
+			params := op.Rule.params()
+			delete(params, "delete")
+			params["pos"] = op.Pos
+			err = digests.write(c, "POST", rulesPath, "", params)
+		}
+		if err != nil {
+			return changes, err
+		}
+	}
+	if isNodeFirewall(path) {
+		return
+	}
+
+	aliasChanges, err := c.applyFirewallAliases(path, digests, current.Aliases, doc.Aliases, dryRun)
+	changes = append(changes, aliasChanges...)
+	if err != nil {
+		return changes, err
+	}
+	ipSetChanges, err := c.applyFirewallIpSets(path, digests, current.IpSets, doc.IpSets, dryRun)
+	changes = append(changes, ipSetChanges...)
+	return changes, err
+}
+
+func (c *Client) applyFirewallAliases(path string, digests firewallDigests, current []FirewallAlias, desired []FirewallAlias, dryRun bool) (changes []FirewallChange, err error) {
+	aliasesPath := path + "/aliases"
+	existing := map[string]FirewallAlias{}
+	for _, alias := range current {
+		existing[alias.Name] = alias
+	}
+	wanted := map[string]bool{}
+	for _, alias := range desired {
+		wanted[alias.Name] = true
+		params := map[string]interface{}{"cidr": alias.Cidr, "comment": alias.Comment}
+		old, exists := existing[alias.Name]
+		switch {
+		case !exists:
+			changes = append(changes, FirewallChange{Op: "add", Kind: "alias", Name: alias.Name})
+			if !dryRun {
+				params["name"] = alias.Name
+				err = digests.write(c, "POST", aliasesPath, "", params)
+			}
+		case old != alias:
+			changes = append(changes, FirewallChange{Op: "update", Kind: "alias", Name: alias.Name})
+			if !dryRun {
+				err = digests.write(c, "PUT", aliasesPath, ApiPath(alias.Name), params)
+			}
+		}
+		if err != nil {
+			return changes, err
+		}
+	}
+	for _, alias := range current {
+		if wanted[alias.Name] {
+			continue
+		}
+		changes = append(changes, FirewallChange{Op: "delete", Kind: "alias", Name: alias.Name})
+		if !dryRun {
+			err = digests.write(c, "DELETE", aliasesPath, ApiPath(alias.Name), nil)
+			if err != nil {
+				return changes, err
+			}
+		}
+	}
+	return
+}
+
+func (c *Client) applyFirewallIpSets(path string, digests firewallDigests, current []FirewallIpSet, desired []FirewallIpSet, dryRun bool) (changes []FirewallChange, err error) {
+	ipSetsPath := path + "/ipset"
+	existing := map[string]FirewallIpSet{}
+	for _, ipSet := range current {
+		existing[ipSet.Name] = ipSet
+	}
+	wanted := map[string]bool{}
+	for _, ipSet := range desired {
+		wanted[ipSet.Name] = true
+		old, exists := existing[ipSet.Name]
+		if !exists || old.Comment != ipSet.Comment {
+			op := "add"
+			params := map[string]interface{}{"name": ipSet.Name, "comment": ipSet.Comment}
+			if exists {
+				// Renaming to itself updates the comment.
+				op = "update"
+				params["rename"] = ipSet.Name
+			}
+			changes = append(changes, FirewallChange{Op: op, Kind: "ipset", Name: ipSet.Name})
+			if !dryRun {
+				err = digests.write(c, "POST", ipSetsPath, "", params)
+				if err != nil {
+					return changes, err
+				}
+			}
+		}
+		entryChanges, err := c.applyFirewallIpSetEntries(ipSetsPath, digests, ipSet.Name, old.Entries, ipSet.Entries, dryRun)
+		changes = append(changes, entryChanges...)
+		if err != nil {
+			return changes, err
+		}
+	}
+	for _, ipSet := range current {
+		if wanted[ipSet.Name] {
+			continue
+		}
+		// IP sets must be emptied before being deleted.
+		entryChanges, err := c.applyFirewallIpSetEntries(ipSetsPath, digests, ipSet.Name, ipSet.Entries, nil, dryRun)
+		changes = append(changes, entryChanges...)
+		if err != nil {
+			return changes, err
+		}
+		changes = append(changes, FirewallChange{Op: "delete", Kind: "ipset", Name: ipSet.Name})
+		if !dryRun {
+			// Deleting an IP set takes no digest, the one of the listing is only refreshed.
+			_, err = c.session.Delete(ipSetsPath+ApiPath(ipSet.Name), nil, nil)
+			if err != nil {
+				return changes, err
+			}
+			entries, err := getApiData[[]map[string]interface{}](c, ipSetsPath)
+			if err != nil {
+				return changes, err
+			}
+			digests[ipSetsPath] = listDigest(entries)
+		}
+	}
+	return
+}
+
+func (c *Client) applyFirewallIpSetEntries(ipSetsPath string, digests firewallDigests, name string, current []FirewallIpSetEntry, desired []FirewallIpSetEntry, dryRun bool) (changes []FirewallChange, err error) {
+	// CIDRs hold a slash, ApiPath keeps them a single segment.
+	setPath := ipSetsPath + ApiPath(name)
+	existing := map[string]FirewallIpSetEntry{}
+	for _, entry := range current {
+		existing[entry.Cidr] = entry
+	}
+	wanted := map[string]bool{}
+	for _, entry := range desired {
+		wanted[entry.Cidr] = true
+		params := map[string]interface{}{"nomatch": entry.NoMatch, "comment": entry.Comment}
+		old, exists := existing[entry.Cidr]
+		switch {
+		case !exists:
+			changes = append(changes, FirewallChange{Op: "add", Kind: "ipset-entry", Name: name + "/" + entry.Cidr})
+			if !dryRun {
+				params["cidr"] = entry.Cidr
+				err = digests.write(c, "POST", setPath, "", params)
+			}
+		case old != entry:
+			changes = append(changes, FirewallChange{Op: "update", Kind: "ipset-entry", Name: name + "/" + entry.Cidr})
+			if !dryRun {
+				err = digests.write(c, "PUT", setPath, ApiPath(entry.Cidr), params)
+			}
+		}
+		if err != nil {
+			return changes, err
+		}
+	}
+	for _, entry := range current {
+		if wanted[entry.Cidr] {
+			continue
+		}
+		changes = append(changes, FirewallChange{Op: "delete", Kind: "ipset-entry", Name: name + "/" + entry.Cidr})
+		if !dryRun {
+			err = digests.write(c, "DELETE", setPath, ApiPath(entry.Cidr), nil)
+			if err != nil {
+				return changes, err
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,82 @@
+package proxmox
+
+import (
+	"reflect"
+	"testing"
+)
+
+// applyFirewallRuleOps - rules resulting from ops applied to rules, the way the API applies them.
+func applyFirewallRuleOps(rules []FirewallRule, ops []FirewallRuleOp) []FirewallRule {
+	rules = append([]FirewallRule{}, rules...)
+	for _, op := range ops {
+		switch op.Op {
+		case "delete":
+			rules = append(rules[:op.Pos], rules[op.Pos+1:]...)
+		case "add":
+			rules = append(rules[:op.Pos], append([]FirewallRule{op.Rule}, rules[op.Pos:]...)...)
+		case "move":
+			rule := rules[op.Pos]
+			rules = append(rules[:op.Pos], rules[op.Pos+1:]...)
+			rules = append(rules[:op.MoveTo], append([]FirewallRule{rule}, rules[op.MoveTo:]...)...)
+		}
+	}
+	return rules
+}
+
+func TestDiffFirewallRules(t *testing.T) {
+	a := FirewallRule{Type: "in", Action: "ACCEPT", Enable: true, Dport: "22", Proto: "tcp"}
+	b := FirewallRule{Type: "in", Action: "ACCEPT", Enable: true, Dport: "443", Proto: "tcp"}
+	c := FirewallRule{Type: "in", Action: "DROP", Enable: true}
+	d := FirewallRule{Type: "out", Action: "ACCEPT", Enable: true, Dest: "10.0.0.0/8"}
+	x := FirewallRule{Type: "in", Action: "ACCEPT", Enable: true, Macro: "Ping"}
+	disabledA := a
+	disabledA.Enable = false
+
+	for _, test := range []struct {
+		name    string
+		current []FirewallRule
+		desired []FirewallRule
+		want    []FirewallRuleOp
+	}{
+		{"unchanged", []FirewallRule{a, b}, []FirewallRule{a, b}, nil},
+		{"empty", nil, nil, nil},
+		{"add first", []FirewallRule{a, b}, []FirewallRule{x, a, b}, []FirewallRuleOp{
+			{Op: "add", Pos: 0, Rule: x},
+		}},
+		{"add to empty", nil, []FirewallRule{a, b}, []FirewallRuleOp{
+			{Op: "add", Pos: 0, Rule: a},
+			{Op: "add", Pos: 1, Rule: b},
+		}},
+		{"delete last first", []FirewallRule{a, b, c}, []FirewallRule{b}, []FirewallRuleOp{
+			{Op: "delete", Pos: 2},
+			{Op: "delete", Pos: 0},
+		}},
+		{"swap", []FirewallRule{a, b}, []FirewallRule{b, a}, []FirewallRuleOp{
+			{Op: "move", Pos: 1, MoveTo: 0},
+		}},
+		{"move, add and move", []FirewallRule{a, b, c}, []FirewallRule{b, d, c, a}, []FirewallRuleOp{
+			{Op: "move", Pos: 1, MoveTo: 0},
+			{Op: "add", Pos: 1, Rule: d},
+			{Op: "move", Pos: 3, MoveTo: 2},
+		}},
+		{"changed rule", []FirewallRule{a, b}, []FirewallRule{disabledA, b}, []FirewallRuleOp{
+			{Op: "delete", Pos: 0},
+			{Op: "add", Pos: 0, Rule: disabledA},
+		}},
+		// Identical rules are matched first to first.
+		{"duplicates", []FirewallRule{c, a, c}, []FirewallRule{a, c}, []FirewallRuleOp{
+			{Op: "delete", Pos: 2},
+			{Op: "move", Pos: 1, MoveTo: 0},
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ops := DiffFirewallRules(test.current, test.desired)
+			if !reflect.DeepEqual(ops, test.want) {
+				t.Errorf("ops = %+v, want %+v", ops, test.want)
+			}
+			if got := applyFirewallRuleOps(test.current, ops); !reflect.DeepEqual(got, append([]FirewallRule{}, test.desired...)) {
+				t.Errorf("applying ops gives %+v, want %+v", got, test.desired)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package proxmox
+
+import (
+	"fmt"
+)
+
+// HaOptions - how managed operations deal with a guest that is an HA resource.
+type HaOptions struct {
+	// Remove the HA resource instead of only setting it to ignored during the operation.
+	Remove bool
+	// Put the HA resource back as it was once the operation is done (ignored by delete).
+	Restore bool
+}
+
+// haSid - HA resource id of a guest, `vm:100` or `ct:100`.
+func haSid(vmr *VmRef) string {
+	if vmr.vmType == "lxc" {
+		return fmt.Sprintf("ct:%d", vmr.vmId)
+	}
+	return fmt.Sprintf("vm:%d", vmr.vmId)
+}
+
+// GetHaResource - HA resource config of the guest, nil when the guest is not managed by HA.
+func (c *Client) GetHaResource(vmr *VmRef) (haResource map[string]interface{}, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := getApiData[[]map[string]interface{}](c, "/cluster/ha/resources")
+	if err != nil {
+		return nil, err
+	}
+	sid := haSid(vmr)
+	for _, resource := range resources {
+		if GetString(resource, "sid") == sid {
+			return resource, nil
+		}
+	}
+	return nil, nil
+}
+
+// IsHaManaged - is the guest an HA resource?
+func (c *Client) IsHaManaged(vmr *VmRef) (bool, error) {
+	haResource, err := c.GetHaResource(vmr)
+	return haResource != nil, err
+}
+
+// SetHaState - request an HA state (started, stopped, disabled, ignored) for the guest.
+func (c *Client) SetHaState(vmr *VmRef, state string) (err error) {
+	reqbody := ParamsToBody(map[string]interface{}{"state": state})
+	url := ApiPath("cluster", "ha", "resources", haSid(vmr))
+	_, err = c.session.Put(url, nil, nil, &reqbody)
+	return
+}
+
+// AddHaResource - make the guest an HA resource, params are state, group, max_restart, max_relocate, comment.
+func (c *Client) AddHaResource(vmr *VmRef, params map[string]interface{}) (err error) {
+	haParams := map[string]interface{}{"sid": haSid(vmr)}
+	for k, v := range params {
+		haParams[k] = v
+	}
+	reqbody := ParamsToBody(haParams)
+	_, err = c.session.Post("/cluster/ha/resources", nil, nil, &reqbody)
+	return
+}
+
+// RemoveHaResource - stop managing the guest with HA, the guest itself is left untouched.
+func (c *Client) RemoveHaResource(vmr *VmRef) (err error) {
+	url := ApiPath("cluster", "ha", "resources", haSid(vmr))
+	_, err = c.session.Delete(url, nil, nil)
+	return
+}
+
+// releaseHa - make the CRM leave the guest alone, returns its HA resource as it was, nil when the
+// guest is not managed by HA.
+func (c *Client) releaseHa(vmr *VmRef, remove bool) (haResource map[string]interface{}, err error) {
+	haResource, err = c.GetHaResource(vmr)
+	if err != nil || haResource == nil {
+		return nil, err
+	}
+	if remove {
+		err = c.RemoveHaResource(vmr)
+	} else {
+		err = c.SetHaState(vmr, "ignored")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return haResource, nil
+}
+
+// restoreHa - put back haResource released by releaseHa, with restoreState instead of the saved
+// state when not empty.
+func (c *Client) restoreHa(vmr *VmRef, haResource map[string]interface{}, removed bool, restoreState string) (err error) {
+	if restoreState == "" {
+		restoreState = GetString(haResource, "state")
+	}
+	if !removed {
+		return c.SetHaState(vmr, restoreState)
+	}
+	restoreParams := map[string]interface{}{}
+	for _, key := range []string{"group", "max_restart", "max_relocate", "comment"} {
+		if value, isSet := haResource[key]; isSet {
+			restoreParams[key] = value
+		}
+	}
+	if restoreState != "" {
+		restoreParams["state"] = restoreState
+	}
+	return c.AddHaResource(vmr, restoreParams)
+}
+
+// withHaReleased - run operation while the CRM leaves the guest alone, then restore HA if requested.
+// restoreState replaces the saved HA state on restore, empty keeps it (e.g. `stopped` after a stop,
+// so the CRM does not start the guest again).
+func (c *Client) withHaReleased(vmr *VmRef, opts HaOptions, restoreState string, operation func() error) (err error) {
+	haResource, err := c.releaseHa(vmr, opts.Remove)
+	if err != nil {
+		return err
+	}
+	if haResource == nil {
+		return operation()
+	}
+
+	err = operation()
+
+	if opts.Restore {
+		restoreErr := c.restoreHa(vmr, haResource, opts.Remove, restoreState)
+		if err == nil {
+			err = restoreErr
+		}
+	}
+	return
+}
+
+// ManagedStopVm - stop a guest without the HA manager starting it again, a restored HA resource
+// is set to stopped.
+func (c *Client) ManagedStopVm(vmr *VmRef, opts HaOptions) (exitStatus string, err error) {
+	err = c.withHaReleased(vmr, opts, "stopped", func() (opErr error) {
+		exitStatus, opErr = c.StopVm(vmr)
+		return
+	})
+	return
+}
+
+// ManagedMigrateVm - migrate a guest to targetNode while HA is released.
+func (c *Client) ManagedMigrateVm(vmr *VmRef, targetNode string, online bool, opts HaOptions) (exitStatus string, err error) {
+	err = c.withHaReleased(vmr, opts, "", func() (opErr error) {
+		exitStatus, opErr = c.MigrateVm(vmr, targetNode, online)
+		return
+	})
+	return
+}
+
+// ManagedDeleteVm - remove the guest from HA, stop it and delete it.
+func (c *Client) ManagedDeleteVm(vmr *VmRef) (exitStatus string, err error) {
+	err = c.withHaReleased(vmr, HaOptions{Remove: true}, "", func() (opErr error) {
+		vmState, opErr := c.GetVmState(vmr)
+		if opErr != nil {
+			return
+		}
+		if vmState["status"] != "stopped" {
+			_, opErr = c.StopVm(vmr)
+			if opErr != nil {
+				return
+			}
+		}
+		exitStatus, opErr = c.DeleteVm(vmr)
+		return
+	})
+	return
+}
@@ -0,0 +1,123 @@
+package proxmox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Names of the checks run by HealthCheck.
+const (
+	HealthConnectivity = "connectivity"
+	HealthAuth         = "authentication"
+	HealthQuorum       = "quorum"
+	HealthResponsive   = "responsiveness"
+)
+
+// HealthSlowThreshold - API answers slower than this fail the responsiveness check.
+var HealthSlowThreshold = 5 * time.Second
+
+// HealthCheckResult - outcome of one check, Err is nil when it passed.
+type HealthCheckResult struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// HealthReport - outcome of HealthCheck, checks are in the order they ran.
+type HealthReport struct {
+	Checks []HealthCheckResult
+}
+
+// Healthy - did every check pass?
+func (report HealthReport) Healthy() bool {
+	return report.Err() == nil
+}
+
+// Err - error of the first failing check, nil when all passed.
+func (report HealthReport) Err() error {
+	for _, check := range report.Checks {
+		if check.Err != nil {
+			return check.Err
+		}
+	}
+	return nil
+}
+
+// healthGet - GET path bounded by ctx, data is decoded into the data field of the response.
+func (c *Client) healthGet(ctx context.Context, path string, data interface{}) (err error) {
+	req, err := c.session.NewRequest("GET", c.session.ApiUrl+path, nil, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.session.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(&ApiResponse[interface{}]{Data: data})
+}
+
+// HealthCheck - Check that the API is reachable, that the session is still authenticated, that the
+// cluster is quorate and that the API answers within HealthSlowThreshold. Checks after a failing
+// connectivity or authentication check are skipped, err is set when ctx ends before completion.
+func (c *Client) HealthCheck(ctx context.Context) (report HealthReport, err error) {
+	start := time.Now()
+	var version map[string]interface{}
+	versionErr := c.healthGet(ctx, "/version", &version)
+	elapsed := time.Since(start)
+	if ctx.Err() != nil {
+		return report, ctx.Err()
+	}
+
+	var apiErr *ApiError
+	isApiErr := errors.As(versionErr, &apiErr)
+	connectivity := HealthCheckResult{Name: HealthConnectivity, Duration: elapsed}
+	if versionErr != nil && !isApiErr {
+		connectivity.Err = versionErr
+	}
+	report.Checks = append(report.Checks, connectivity)
+	if connectivity.Err != nil {
+		return report, nil
+	}
+	auth := HealthCheckResult{Name: HealthAuth, Duration: elapsed, Err: versionErr}
+	if isApiErr && apiErr.Code == http.StatusUnauthorized {
+		auth.Err = errors.New("session is not authenticated: " + versionErr.Error())
+	}
+	report.Checks = append(report.Checks, auth)
+	if auth.Err != nil {
+		return report, nil
+	}
+
+	start = time.Now()
+	var clusterStatus []map[string]interface{}
+	quorum := HealthCheckResult{Name: HealthQuorum}
+	quorum.Err = c.healthGet(ctx, "/cluster/status", &clusterStatus)
+	quorum.Duration = time.Since(start)
+	if ctx.Err() != nil {
+		return report, ctx.Err()
+	}
+	if quorum.Err == nil {
+		// Standalone nodes have no cluster entry and are always quorate.
+		for _, entry := range clusterStatus {
+			if GetString(entry, "type") == "cluster" && GetIntDefault(entry, "quorate", 0) != 1 {
+				quorum.Err = errors.New("cluster " + GetString(entry, "name") + " is not quorate")
+			}
+		}
+	}
+	report.Checks = append(report.Checks, quorum)
+
+	responsive := HealthCheckResult{Name: HealthResponsive, Duration: elapsed}
+	slowest := elapsed
+	if quorum.Duration > slowest {
+		slowest = quorum.Duration
+	}
+	if slowest > HealthSlowThreshold {
+		responsive.Err = errors.New("API answered in " + slowest.String() + ", more than " + HealthSlowThreshold.String())
+	}
+	report.Checks = append(report.Checks, responsive)
+	return report, nil
+}
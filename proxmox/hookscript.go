@@ -0,0 +1,35 @@
+package proxmox
+
+import (
+	"fmt"
+	"io"
+)
+
+// SetHookscript - Attach a hook script to a VM or container, volid is a snippet like `local:snippets/hook.sh`.
+func (c *Client) SetHookscript(vmr *VmRef, volid string) (exitStatus interface{}, err error) {
+	return c.setGuestConfig(vmr, map[string]interface{}{"hookscript": volid})
+}
+
+// ClearHookscript - Detach the hook script of a VM or container.
+func (c *Client) ClearHookscript(vmr *VmRef) (exitStatus interface{}, err error) {
+	return c.setGuestConfig(vmr, map[string]interface{}{"delete": "hookscript"})
+}
+
+// UploadHookscript - Upload script as a snippet on storage then attach it to the guest.
+// The storage must have the `snippets` content type enabled and be available on the guest node.
+func (c *Client) UploadHookscript(vmr *VmRef, storage string, filename string, script io.Reader) (volid string, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return "", err
+	}
+	err = c.Upload(vmr.node, storage, "snippets", filename, script)
+	if err != nil {
+		return "", err
+	}
+	volid = fmt.Sprintf("%s:snippets/%s", storage, filename)
+	_, err = c.SetHookscript(vmr, volid)
+	if err != nil {
+		return "", err
+	}
+	return
+}
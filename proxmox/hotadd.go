@@ -0,0 +1,144 @@
+package proxmox
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// HotAddResult - outcome of HotAddResources. GuestCpus and GuestMemoryMB are what the guest agent
+// reports online, they stay 0 when the agent is not enabled.
+type HotAddResult struct {
+	// Config keys applied to the running VM.
+	Applied []string
+	// Config keys left pending until the next reboot.
+	Pending     []string
+	NeedsReboot bool
+
+	GuestConfirmed bool
+	GuestCpus      int
+	GuestMemoryMB  int
+}
+
+// agentResult - result of a guest agent GET command.
+func agentResult[T any](c *Client, vmr *VmRef, command string) (result T, err error) {
+	data, err := getApiData[*struct {
+		Result T `json:"result"`
+	}](c, agentUrl(vmr, command))
+	if err != nil {
+		return result, err
+	}
+	if data == nil {
+		return result, fmt.Errorf("agent %s not readable", command)
+	}
+	return data.Result, nil
+}
+
+// GetGuestResources - online vCPUs and memory (MB) as seen by the guest agent.
+func (c *Client) GetGuestResources(vmr *VmRef) (cpus int, memoryMB int, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return 0, 0, err
+	}
+	vcpus, err := agentResult[[]struct {
+		Online FlexBool `json:"online"`
+	}](c, vmr, "get-vcpus")
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, vcpu := range vcpus {
+		if vcpu.Online {
+			cpus++
+		}
+	}
+	blockInfo, err := agentResult[struct {
+		Size FlexInt `json:"size"`
+	}](c, vmr, "get-memory-block-info")
+	if err != nil {
+		return 0, 0, err
+	}
+	blocks, err := agentResult[[]struct {
+		Online FlexBool `json:"online"`
+	}](c, vmr, "get-memory-blocks")
+	if err != nil {
+		return 0, 0, err
+	}
+	online := 0
+	for _, block := range blocks {
+		if block.Online {
+			online++
+		}
+	}
+	return cpus, int(int64(online) * int64(blockInfo.Size) / (1024 * 1024)), nil
+}
+
+// HotAddResources - Grow a running VM to newCores vCPUs and newMemory MB (0 leaves either unchanged).
+// vCPUs up to sockets*cores are hot-plugged through the vcpus option when CPU hotplug is enabled,
+// memory needs memory hotplug and NUMA. The guest agent, when enabled, is then asked what the guest
+// sees until it confirms, at most agentTimeout; guests without auto-onlining of hot-plugged CPUs and
+// memory do not confirm the change.
+func (c *Client) HotAddResources(vmr *VmRef, newCores int, newMemory int, agentTimeout time.Duration) (result *HotAddResult, err error) {
+	if newCores < 0 || newMemory < 0 {
+		return nil, errors.New("cores and memory must not be negative")
+	}
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return nil, err
+	}
+	if vmr.vmType != "qemu" {
+		return nil, fmt.Errorf("guest %d is not a VM", vmr.vmId)
+	}
+	sockets := GetIntDefault(vmConfig, "sockets", 1)
+	cores := GetIntDefault(vmConfig, "cores", 1)
+
+	params := map[string]interface{}{}
+	if newCores > 0 {
+		// Growing the topology, or any change without CPU hotplug, is only applied by a reboot.
+		if newCores > sockets*cores {
+			params["cores"] = (newCores + sockets - 1) / sockets
+		}
+		params["vcpus"] = newCores
+	}
+	if newMemory > 0 {
+		if inArray(hotplugCategories(GetString(vmConfig, "hotplug")), "memory") && !Itob(GetIntDefault(vmConfig, "numa", 0)) {
+			return nil, errors.New("memory hotplug requires NUMA")
+		}
+		params["memory"] = newMemory
+	}
+	if len(params) == 0 {
+		return &HotAddResult{}, nil
+	}
+
+	_, err = c.SetVmConfig(vmr, params)
+	if err != nil {
+		return nil, err
+	}
+	result = &HotAddResult{}
+	result.NeedsReboot, result.Pending, err = c.NeedsReboot(vmr)
+	if err != nil {
+		return nil, err
+	}
+	for key := range params {
+		if !inArray(result.Pending, key) {
+			result.Applied = append(result.Applied, key)
+		}
+	}
+
+	if agent := ParseQemuAgent(GetString(vmConfig, "agent")); !agent.Enabled || len(result.Applied) == 0 {
+		return result, nil
+	}
+	// The guest onlines hot-plugged resources asynchronously.
+	deadline := time.Now().Add(agentTimeout)
+	for {
+		result.GuestCpus, result.GuestMemoryMB, err = c.GetGuestResources(vmr)
+		if err != nil {
+			return result, err
+		}
+		result.GuestConfirmed = (newCores == 0 || inArray(result.Pending, "vcpus") || result.GuestCpus >= newCores) &&
+			(newMemory == 0 || inArray(result.Pending, "memory") || result.GuestMemoryMB >= newMemory)
+		if result.GuestConfirmed || time.Now().After(deadline) {
+			return result, nil
+		}
+		time.Sleep(TaskStatusCheckInterval * time.Second)
+	}
+}
@@ -0,0 +1,156 @@
+package proxmox
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// A VM created with an idempotency key carries the tag `idem-<key>`, found in a single cluster
+// listing, and the key in a description line, written by the clone call itself so that a clone
+// whose task outlived the client can be found before its tag is set.
+
+// IdempotencyMarker - prefix of the description line holding the idempotency key of a VM.
+const IdempotencyMarker = "proxmox-api-go-idempotency-key:"
+
+// Keys become tags, they are restricted to the tag charset.
+var rxIdempotencyKey = regexp.MustCompile(`^[a-z0-9_][a-z0-9_.+-]*$`)
+
+// IdempotencyTag - tag recording key on a VM.
+func IdempotencyTag(key string) string {
+	return "idem-" + key
+}
+
+func checkIdempotencyKey(key string) error {
+	if !rxIdempotencyKey.MatchString(key) {
+		return fmt.Errorf("idempotency key '%s' can only contain lowercase letters, digits, '_', '.', '+' and '-'", key)
+	}
+	return nil
+}
+
+// withIdempotencyMarker - description with the key line appended.
+func withIdempotencyMarker(description string, key string) string {
+	if description != "" && !strings.HasSuffix(description, "\n") {
+		description += "\n"
+	}
+	return description + IdempotencyMarker + " " + key + "\n"
+}
+
+// FindVmByIdempotencyKey - Find the VM created with key, nil when there is none. name narrows the
+// search of VMs not tagged yet (clones still running), which needs a config read per VM named so.
+func (c *Client) FindVmByIdempotencyKey(key string, name string) (vmr *VmRef, err error) {
+	err = checkIdempotencyKey(key)
+	if err != nil {
+		return nil, err
+	}
+	vms, err := c.GetVmResources()
+	if err != nil {
+		return nil, err
+	}
+	tag := IdempotencyTag(key)
+	var candidates []*VmRef
+	for _, vm := range vms {
+		found := NewVmRef(int(vm.VmId))
+		found.SetNode(vm.Node)
+		found.SetVmType(vm.Type)
+		if inArray(splitTags(vm.Tags), tag) {
+			return found, nil
+		}
+		if name != "" && vm.Name == name {
+			candidates = append(candidates, found)
+		}
+	}
+	marker := IdempotencyMarker + " " + key
+	for _, candidate := range candidates {
+		vmConfig, err := c.GetVmConfig(candidate)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(GetString(vmConfig, "description"), "\n") {
+			if strings.TrimSpace(line) == marker {
+				return candidate, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// CreateQemuVmIdempotent - CreateQemuVm recording key on the VM. When a VM was already created with
+// key, e.g. by an attempt that timed out, it is returned with created unset instead of creating another.
+// A creation whose outcome is unknown is resolved the same way, by looking the VM up by key.
+func (c *Client) CreateQemuVmIdempotent(node string, key string, vmParams map[string]interface{}) (vmr *VmRef, created bool, err error) {
+	vmr, err = c.FindVmByIdempotencyKey(key, GetString(vmParams, "name"))
+	if err != nil || vmr != nil {
+		return vmr, false, err
+	}
+	params := map[string]interface{}{}
+	for k, v := range vmParams {
+		params[k] = v
+	}
+	params["tags"] = strings.Join(append(splitTags(GetString(vmParams, "tags")), IdempotencyTag(key)), ";")
+	params["description"] = withIdempotencyMarker(GetString(vmParams, "description"), key)
+	exitStatus, err := c.CreateQemuVm(node, params)
+	var leftovers *ErrCreationLeftovers
+	if errors.As(err, &leftovers) && leftovers.VmExists {
+		// The VM may have been created, the request got no answer or its task is still running:
+		// it is looked up by key instead, and returned once its creation released its lock.
+		found, findErr := c.FindVmByIdempotencyKey(key, GetString(vmParams, "name"))
+		if findErr != nil || found == nil {
+			return nil, false, err
+		}
+		timeout, _, _ := c.taskPolling()
+		return found, true, c.WaitForUnlock(found, int(timeout/time.Second))
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if exitStatus != exitStatusSuccess {
+		return nil, false, fmt.Errorf("creation of VM failed: %s", exitStatus)
+	}
+	vmr = NewVmRef(GetIntDefault(params, "vmid", 0))
+	vmr.SetNode(node)
+	vmr.SetVmType("qemu")
+	return vmr, true, nil
+}
+
+// CloneQemuVmIdempotent - CloneQemuVm of sourceVmr recording key on the clone. When a VM was already
+// cloned with key it is returned with created unset instead of cloning again.
+func (c *Client) CloneQemuVmIdempotent(sourceVmr *VmRef, key string, vmParams map[string]interface{}) (vmr *VmRef, created bool, err error) {
+	vmr, err = c.FindVmByIdempotencyKey(key, GetString(vmParams, "name"))
+	if err != nil || vmr != nil {
+		return vmr, false, err
+	}
+	err = c.CheckVmRef(sourceVmr)
+	if err != nil {
+		return nil, false, err
+	}
+	params := map[string]interface{}{}
+	for k, v := range vmParams {
+		params[k] = v
+	}
+	params["description"] = withIdempotencyMarker(GetString(vmParams, "description"), key)
+	exitStatus, err := c.CloneQemuVm(sourceVmr, params)
+	if err != nil {
+		return nil, false, err
+	}
+	if exitStatus != exitStatusSuccess {
+		return nil, false, fmt.Errorf("clone of VM %d failed: %s", sourceVmr.vmId, exitStatus)
+	}
+
+	// Clones inherit the tags of their source, the key tag is added to them.
+	vmr = NewVmRef(GetIntDefault(params, "newid", 0))
+	vmr.SetNode(sourceVmr.node)
+	if target := GetString(params, "target"); target != "" {
+		vmr.SetNode(target)
+	}
+	vmr.SetVmType("qemu")
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return vmr, true, err
+	}
+	tags := append(splitTags(GetString(vmConfig, "tags")), IdempotencyTag(key))
+	_, err = c.SetVmConfig(vmr, map[string]interface{}{"tags": strings.Join(tags, ";")})
+	return vmr, true, err
+}
@@ -0,0 +1,98 @@
+package proxmox
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Without the guest agent the VM addresses are guessed from what Proxmox knows about them:
+// the static cloud-init ipconfigN settings and the SDN IPAM allocations (including the leases
+// of the SDN DHCP). These are configured addresses, not addresses seen on the network: the API
+// exposes no ARP/neighbor table of the nodes, so a guest may not use them and guests getting
+// their address from an external DHCP server cannot be found.
+
+// VmIp - address configured for a VM network interface, Source is `configured:cloudinit` or
+// `configured:ipam:<name>`.
+type VmIp struct {
+	Interface string // net0, net1...
+	Mac       string
+	Ip        string
+	Source    string
+}
+
+var (
+	rxNetConfig = regexp.MustCompile(`^net(\d+)$`)
+	rxMac       = regexp.MustCompile(`(?i)^[0-9a-f]{2}(:[0-9a-f]{2}){5}$`)
+)
+
+// vmMacs - MAC address of each network interface of a VM config, by interface.
+func vmMacs(vmConfig map[string]interface{}) map[string]string {
+	macs := map[string]string{}
+	for key := range vmConfig {
+		if !rxNetConfig.MatchString(key) {
+			continue
+		}
+		// `virtio=BC:24:11:00:00:01,bridge=vmbr0` or `model=virtio,macaddr=BC:...`.
+		for _, item := range strings.Split(GetString(vmConfig, key), ",") {
+			if _, mac, ok := strings.Cut(item, "="); ok && rxMac.MatchString(mac) {
+				macs[key] = strings.ToUpper(mac)
+			}
+		}
+	}
+	return macs
+}
+
+// GetVmIpsWithoutAgent - Best effort addresses of a VM without guest agent, configured in its
+// cloud-init settings and the SDN IPAM, see above. Interfaces with dhcp cloud-init settings are only found in the IPAM.
+func (c *Client) GetVmIpsWithoutAgent(vmr *VmRef) (ips []VmIp, err error) {
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return nil, err
+	}
+	macs := vmMacs(vmConfig)
+	for iface, mac := range macs {
+		ipconfig := GetString(vmConfig, "ipconfig"+strings.TrimPrefix(iface, "net"))
+		confMap := ParseConf(ipconfig, ",", "=")
+		for _, key := range []string{"ip", "ip6"} {
+			ip, _ := confMap[key].(string)
+			if ip != "" && ip != "dhcp" && ip != "auto" {
+				ips = append(ips, VmIp{Interface: iface, Mac: mac, Ip: strings.Split(ip, "/")[0], Source: "configured:cloudinit"})
+			}
+		}
+	}
+
+	ipams, err := c.GetSdnIpams()
+	if IsNotFound(err) {
+		// SDN is optional, older clusters have no IPAM.
+		return sortVmIps(ips), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, ipam := range ipams {
+		entries, err := c.GetSdnIpamEntries(ipam.Ipam)
+		if IsNotFound(err) {
+			// IPAM status needs PVE 8.1.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			for iface, mac := range macs {
+				if strings.EqualFold(entry.Mac, mac) {
+					ips = append(ips, VmIp{Interface: iface, Mac: mac, Ip: entry.Ip, Source: "configured:ipam:" + ipam.Ipam})
+				}
+			}
+		}
+	}
+	return sortVmIps(ips), nil
+}
+
+func sortVmIps(ips []VmIp) []VmIp {
+	sort.SliceStable(ips, func(i, j int) bool {
+		return ips[i].Interface < ips[j].Interface
+	})
+	return ips
+}
@@ -0,0 +1,103 @@
+package proxmox
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Scheduled jobs of /cluster/jobs (PVE 7.1+), jobType is the kind of job, e.g. `realm-sync`.
+// Backup jobs have their own endpoint (/cluster/backup).
+
+// ClusterJob - scheduled job, Config holds the type specific options (realm, scope...).
+type ClusterJob struct {
+	Id       string
+	Schedule string
+	Enabled  bool
+	Comment  string
+	NextRun  int64 // epoch, 0 when unknown
+	LastRun  int64
+	Config   map[string]interface{}
+}
+
+func newClusterJob(jobMap map[string]interface{}) ClusterJob {
+	return ClusterJob{
+		Id:       GetString(jobMap, "id"),
+		Schedule: GetString(jobMap, "schedule"),
+		Enabled:  GetIntDefault(jobMap, "enabled", 1) == 1,
+		Comment:  GetString(jobMap, "comment"),
+		NextRun:  int64(GetFloatDefault(jobMap, "next-run", 0)),
+		LastRun:  int64(GetFloatDefault(jobMap, "last-run", 0)),
+		Config:   jobMap,
+	}
+}
+
+// ListClusterJobs - Scheduled jobs of jobType.
+func (c *Client) ListClusterJobs(jobType string) (jobs []ClusterJob, err error) {
+	entries, err := getApiData[[]map[string]interface{}](c, ApiPath("cluster", "jobs", jobType))
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		jobs = append(jobs, newClusterJob(entry))
+	}
+	return
+}
+
+// GetClusterJob - Scheduled job id of jobType.
+func (c *Client) GetClusterJob(jobType string, id string) (job *ClusterJob, err error) {
+	jobMap, err := getApiData[map[string]interface{}](c, ApiPath("cluster", "jobs", jobType, id))
+	if err != nil {
+		return nil, err
+	}
+	if jobMap == nil {
+		return nil, &ErrUnexpectedResponse{"cluster job not readable", id}
+	}
+	jobMap["id"] = id
+	clusterJob := newClusterJob(jobMap)
+	return &clusterJob, nil
+}
+
+// CreateClusterJob - Create scheduled job id of jobType, params are the job options besides schedule.
+func (c *Client) CreateClusterJob(jobType string, id string, schedule string, params map[string]interface{}) (err error) {
+	jobParams := map[string]interface{}{"schedule": schedule}
+	for k, v := range params {
+		jobParams[k] = v
+	}
+	reqbody := ParamsToBody(jobParams)
+	_, err = c.session.Post(ApiPath("cluster", "jobs", jobType, id), nil, nil, &reqbody)
+	return
+}
+
+// UpdateClusterJob - Change options of scheduled job id of jobType.
+func (c *Client) UpdateClusterJob(jobType string, id string, params map[string]interface{}) (err error) {
+	reqbody := ParamsToBody(params)
+	_, err = c.session.Put(ApiPath("cluster", "jobs", jobType, id), nil, nil, &reqbody)
+	return
+}
+
+// SetClusterJobEnabled - Enable or disable scheduled job id of jobType.
+func (c *Client) SetClusterJobEnabled(jobType string, id string, enabled bool) (err error) {
+	return c.UpdateClusterJob(jobType, id, map[string]interface{}{"enabled": enabled})
+}
+
+// DeleteClusterJob - Remove scheduled job id of jobType.
+func (c *Client) DeleteClusterJob(jobType string, id string) (err error) {
+	_, err = c.session.Delete(ApiPath("cluster", "jobs", jobType, id), nil, nil)
+	return
+}
+
+// NextScheduleRuns - times of the next iterations runs of a calendar event schedule (`mon..fri 02:00`).
+func (c *Client) NextScheduleRuns(schedule string, iterations int) (runs []time.Time, err error) {
+	params := url.Values{}
+	params.Set("schedule", schedule)
+	params.Set("iterations", strconv.Itoa(iterations))
+	entries, err := getApiDataWithParams[[]map[string]interface{}](c, "/cluster/jobs/schedule-analyze", params)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		runs = append(runs, time.Unix(int64(GetFloatDefault(entry, "timestamp", 0)), 0))
+	}
+	return
+}
@@ -0,0 +1,221 @@
+package proxmox
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrVmLocked - the operation was refused because the guest holds a lock (backup, clone, migrate...).
+type ErrVmLocked struct {
+	Lock string
+	Err  error
+}
+
+func (e *ErrVmLocked) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ErrVmLocked) Unwrap() error {
+	return e.Err
+}
+
+// Matches "VM is locked (backup)", "VM 100 is locked" and "CT 100 is locked (snapshot)".
+var rxVmLocked = regexp.MustCompile(`(?:VM|CT)(?: \d+)? is locked(?: \((\w+)\))?`)
+
+// checkVmLocked - turn errors reporting a guest lock into ErrVmLocked, other errors are returned unchanged.
+func checkVmLocked(err error) error {
+	if err == nil {
+		return nil
+	}
+	match := rxVmLocked.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+	return &ErrVmLocked{Lock: match[1], Err: err}
+}
+
+// IsVmLocked - is err caused by a guest lock?
+func IsVmLocked(err error) bool {
+	var lockedErr *ErrVmLocked
+	return errors.As(err, &lockedErr)
+}
+
+// SetProtection - Enable or disable protection against removal of the guest and its disks.
+func (c *Client) SetProtection(vmr *VmRef, protection bool) (exitStatus interface{}, err error) {
+	return c.setGuestConfig(vmr, map[string]interface{}{"protection": protection})
+}
+
+// GetLock - Get the lock held by the guest, empty when unlocked.
+func (c *Client) GetLock(vmr *VmRef) (lock string, err error) {
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return "", err
+	}
+	lock, _ = vmConfig["lock"].(string)
+	return
+}
+
+// UnlockVm - Remove a stale lock, like `qm unlock`. Only root@pam may skip the lock check.
+func (c *Client) UnlockVm(vmr *VmRef) (exitStatus interface{}, err error) {
+	return c.setGuestConfig(vmr, map[string]interface{}{"delete": "lock", "skiplock": true})
+}
+
+// WaitForUnlock - poll the guest config until its lock is released, timeout is in seconds.
+func (c *Client) WaitForUnlock(vmr *VmRef, timeout int) (err error) {
+	waited := 0
+	for {
+		lock, err := c.GetLock(vmr)
+		if err != nil {
+			return err
+		}
+		if lock == "" {
+			return nil
+		}
+		if waited >= timeout {
+			return &ErrVmLocked{Lock: lock, Err: fmt.Errorf("VM %d is locked (%s)", vmr.vmId, lock)}
+		}
+		time.Sleep(TaskStatusCheckInterval * time.Second)
+		waited = waited + TaskStatusCheckInterval
+	}
+}
+
+// VmLeaseMarker - prefix of the description line holding the advisory lease of a guest.
+const VmLeaseMarker = "proxmox-api-go-lease:"
+
+// Lease owners are written in the description, only a safe charset is accepted.
+var rxLeaseOwner = regexp.MustCompile(`^[\w.@-]+$`)
+
+// Matches the lease line `proxmox-api-go-lease: owner=ctrl-a expires=1700000000`.
+var rxVmLease = regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(VmLeaseMarker) + ` owner=(\S+) expires=(\d+)\n?`)
+
+// Proxmox rejects updates whose digest no longer matches the config.
+var rxDigestMismatch = regexp.MustCompile(`detected modified configuration`)
+
+// VmLease - advisory lease of a guest held by Owner until Expires.
+type VmLease struct {
+	Owner   string
+	Expires time.Time
+}
+
+// ErrVmLeaseHeld - the guest is leased by another owner.
+type ErrVmLeaseHeld struct {
+	VmId  int
+	Lease VmLease
+}
+
+func (e *ErrVmLeaseHeld) Error() string {
+	return fmt.Sprintf("VM %d is leased by '%s' until %s", e.VmId, e.Lease.Owner, e.Lease.Expires.Format(time.RFC3339))
+}
+
+// parseVmLease - lease of a guest description, nil when there is none.
+func parseVmLease(description string) *VmLease {
+	match := rxVmLease.FindStringSubmatch(description)
+	if match == nil {
+		return nil
+	}
+	expires, _ := strconv.ParseInt(match[2], 10, 64)
+	return &VmLease{Owner: match[1], Expires: time.Unix(expires, 0)}
+}
+
+// GetVmLease - Get the advisory lease of the guest, nil when it is not leased or the lease expired.
+func (c *Client) GetVmLease(vmr *VmRef) (lease *VmLease, err error) {
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return nil, err
+	}
+	lease = parseVmLease(GetString(vmConfig, "description"))
+	if lease != nil && time.Now().After(lease.Expires) {
+		return nil, nil
+	}
+	return
+}
+
+// updateVmLease - compare-and-set the lease line of the description: update computes the new
+// description from the current one and its lease, and the write fails if another client changed
+// the config in between, in which case it is retried on the new config.
+func (c *Client) updateVmLease(vmr *VmRef, update func(description string, lease *VmLease) (string, error)) (err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return err
+	}
+	url := ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "config")
+	for tries := 0; ; tries++ {
+		vmConfig, err := c.GetVmConfig(vmr)
+		if err != nil {
+			return err
+		}
+		description := GetString(vmConfig, "description")
+		newDescription, err := update(description, parseVmLease(description))
+		if err != nil || newDescription == description {
+			return err
+		}
+		params := map[string]interface{}{
+			"description": newDescription,
+			"digest":      GetString(vmConfig, "digest"),
+		}
+		if newDescription == "" {
+			params = map[string]interface{}{"delete": "description", "digest": params["digest"]}
+		}
+		reqbody := ParamsToBody(params)
+		_, err = c.session.Put(url, nil, nil, &reqbody)
+		if err == nil || !rxDigestMismatch.MatchString(err.Error()) || tries == 2 {
+			return err
+		}
+	}
+}
+
+// AcquireVmLease - Take, or renew, the advisory lease of the guest for owner during ttl. Leases
+// are only honored by clients calling AcquireVmLease, Proxmox itself ignores them. Fails with
+// ErrVmLeaseHeld while another owner holds an unexpired lease.
+func (c *Client) AcquireVmLease(vmr *VmRef, owner string, ttl time.Duration) (lease *VmLease, err error) {
+	if !rxLeaseOwner.MatchString(owner) {
+		return nil, fmt.Errorf("lease owner '%s' can only contain letters, digits, '_', '.', '@' and '-'", owner)
+	}
+	lease = &VmLease{Owner: owner, Expires: time.Now().Add(ttl)}
+	err = c.updateVmLease(vmr, func(description string, current *VmLease) (string, error) {
+		if current != nil && current.Owner != owner && time.Now().Before(current.Expires) {
+			return "", &ErrVmLeaseHeld{VmId: vmr.vmId, Lease: *current}
+		}
+		line := fmt.Sprintf("%s owner=%s expires=%d\n", VmLeaseMarker, owner, lease.Expires.Unix())
+		if current != nil {
+			return rxVmLease.ReplaceAllLiteralString(description, line), nil
+		}
+		if description != "" && !strings.HasSuffix(description, "\n") {
+			description += "\n"
+		}
+		return description + line, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// ReleaseVmLease - Remove the advisory lease of the guest if owner holds it.
+func (c *Client) ReleaseVmLease(vmr *VmRef, owner string) (err error) {
+	return c.updateVmLease(vmr, func(description string, current *VmLease) (string, error) {
+		if current == nil || current.Owner != owner {
+			return description, nil
+		}
+		return rxVmLease.ReplaceAllLiteralString(description, ""), nil
+	})
+}
+
+// WithVmLease - Run fn while owner holds the advisory lease of the guest, released afterwards.
+// ttl must cover fn, the lease is not renewed while it runs.
+func (c *Client) WithVmLease(vmr *VmRef, owner string, ttl time.Duration, fn func() error) (err error) {
+	_, err = c.AcquireVmLease(vmr, owner, ttl)
+	if err != nil {
+		return err
+	}
+	err = fn()
+	releaseErr := c.ReleaseVmLease(vmr, owner)
+	if err == nil {
+		err = releaseErr
+	}
+	return
+}
@@ -0,0 +1,342 @@
+package proxmox
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Snapshots of containers go through ListSnapshots, CreateSnapshot, DeleteSnapshot and
+// RollbackQemuVm like VMs, the guest type of the VmRef selects the endpoint.
+
+// CloneLxc - Clone a container, vmParams are those of POST /lxc/{vmid}/clone (newid, hostname, target,
+// full, storage...). Linked clones (full=0) need the source to be a template.
+func (c *Client) CloneLxc(vmr *VmRef, vmParams map[string]interface{}) (exitStatus string, err error) {
+	vmr.SetVmType("lxc")
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return "", err
+	}
+	reqbody := ParamsToBody(vmParams)
+	url := ApiPath("nodes", vmr.node, "lxc", vmr.vmId, "clone")
+	if !c.configuration.ParallelClone {
+		c.cloneMutex.Lock()
+		defer c.cloneMutex.Unlock()
+	}
+	resp, err := c.session.Post(url, nil, nil, &reqbody)
+	if err == nil {
+		taskResponse := ResponseJSON(resp)
+		exitStatus, err = c.WaitForCompletion(taskResponse)
+	}
+	return
+}
+
+// CreateTemplate - Convert a stopped VM or container to a template, this cannot be undone.
+func (c *Client) CreateTemplate(vmr *VmRef) (exitStatus string, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return "", err
+	}
+	url := ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "template")
+	resp, err := c.session.Post(url, nil, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	// VMs answer with a task, containers are converted synchronously.
+	taskResponse := ResponseJSON(resp)
+	if _, isTask := taskResponse["data"].(string); isTask {
+		return c.WaitForCompletion(taskResponse)
+	}
+	return exitStatusSuccess, nil
+}
+
+// LxcNetwork - netN interface of a container. Ip and Ip6 are CIDR addresses, `dhcp` or `manual`
+// (Ip6 also accepts `auto`), Rate is in MB/s.
+type LxcNetwork struct {
+	Name     string  `json:"name"`
+	Bridge   string  `json:"bridge"`
+	Hwaddr   string  `json:"hwaddr"`
+	Ip       string  `json:"ip"`
+	Gw       string  `json:"gw"`
+	Ip6      string  `json:"ip6"`
+	Gw6      string  `json:"gw6"`
+	Firewall bool    `json:"firewall"`
+	Mtu      int     `json:"mtu"`
+	Rate     float64 `json:"rate"`
+	Tag      int     `json:"tag"`
+}
+
+var rxLxcNetwork = regexp.MustCompile(`^net(\d+)$`)
+
+// String - netN parameter in Proxmox format `name=eth0,bridge=vmbr0,ip=dhcp`.
+func (network LxcNetwork) String() string {
+	networkParam := QemuDeviceParam{"name=" + network.Name}
+	for _, option := range [][2]string{
+		{"bridge", network.Bridge},
+		{"hwaddr", network.Hwaddr},
+		{"ip", network.Ip},
+		{"gw", network.Gw},
+		{"ip6", network.Ip6},
+		{"gw6", network.Gw6},
+	} {
+		if option[1] != "" {
+			networkParam = append(networkParam, option[0]+"="+option[1])
+		}
+	}
+	if network.Firewall {
+		networkParam = append(networkParam, "firewall=1")
+	}
+	if network.Mtu > 0 {
+		networkParam = append(networkParam, fmt.Sprintf("mtu=%d", network.Mtu))
+	}
+	if network.Rate > 0 {
+		networkParam = append(networkParam, "rate="+strconv.FormatFloat(network.Rate, 'f', -1, 64))
+	}
+	if network.Tag > 0 {
+		networkParam = append(networkParam, fmt.Sprintf("tag=%d", network.Tag))
+	}
+	return strings.Join(networkParam, ",")
+}
+
+// ParseLxcNetwork - read a netN parameter.
+func ParseLxcNetwork(net string) *LxcNetwork {
+	confMap := ParseConf(net, ",", "=")
+	network := &LxcNetwork{}
+	network.Name = GetString(confMap, "name")
+	network.Bridge = GetString(confMap, "bridge")
+	network.Hwaddr = GetString(confMap, "hwaddr")
+	network.Ip = GetString(confMap, "ip")
+	network.Gw = GetString(confMap, "gw")
+	network.Ip6 = GetString(confMap, "ip6")
+	network.Gw6 = GetString(confMap, "gw6")
+	network.Firewall = GetIntDefault(confMap, "firewall", 0) == 1
+	network.Mtu = GetIntDefault(confMap, "mtu", 0)
+	network.Rate = GetFloatDefault(confMap, "rate", 0)
+	network.Tag = GetIntDefault(confMap, "tag", 0)
+	return network
+}
+
+// GetLxcNetworks - network interfaces of a container by their netN number.
+func (c *Client) GetLxcNetworks(vmr *VmRef) (networks map[int]*LxcNetwork, err error) {
+	vmr.SetVmType("lxc")
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return nil, err
+	}
+	networks = map[int]*LxcNetwork{}
+	for key := range vmConfig {
+		match := rxLxcNetwork.FindStringSubmatch(key)
+		if match == nil {
+			continue
+		}
+		networkID, _ := strconv.Atoi(match[1])
+		networks[networkID] = ParseLxcNetwork(GetString(vmConfig, key))
+	}
+	return
+}
+
+// SetLxcNetwork - Add or update the netN interface of a container. Proxmox hot-plugs interfaces of
+// running containers, changes it cannot apply live are left pending until the next restart.
+func (c *Client) SetLxcNetwork(vmr *VmRef, networkID int, network LxcNetwork) (err error) {
+	if networkID < 0 || networkID > 31 {
+		return fmt.Errorf("network id %d out of range 0-31", networkID)
+	}
+	if network.Name == "" {
+		return fmt.Errorf("net%d has no interface name", networkID)
+	}
+	vmr.SetVmType("lxc")
+	_, err = c.setGuestConfig(vmr, map[string]interface{}{
+		fmt.Sprintf("net%d", networkID): network.String(),
+	})
+	return
+}
+
+// DeleteLxcNetwork - Remove the netN interface of a container, running containers lose it immediately.
+func (c *Client) DeleteLxcNetwork(vmr *VmRef, networkID int) (err error) {
+	vmr.SetVmType("lxc")
+	_, err = c.setGuestConfig(vmr, map[string]interface{}{
+		"delete": fmt.Sprintf("net%d", networkID),
+	})
+	return
+}
+
+// LxcDns - hostname and resolver settings of a container, empty nameservers and search domains
+// make the container use those of the host.
+type LxcDns struct {
+	Hostname      string   `json:"hostname"`
+	Nameservers   []string `json:"nameserver"`
+	SearchDomains []string `json:"searchdomain"`
+}
+
+// Validate - check the hostname format and that nameservers are IP addresses.
+func (dns LxcDns) Validate() error {
+	if dns.Hostname != "" && (len(dns.Hostname) > 255 || !rxGuestName.MatchString(dns.Hostname)) {
+		return fmt.Errorf("invalid hostname '%s'", dns.Hostname)
+	}
+	for _, nameserver := range dns.Nameservers {
+		if net.ParseIP(nameserver) == nil {
+			return fmt.Errorf("nameserver '%s' is not an IP address", nameserver)
+		}
+	}
+	for _, domain := range dns.SearchDomains {
+		if !rxGuestName.MatchString(domain) {
+			return fmt.Errorf("invalid search domain '%s'", domain)
+		}
+	}
+	return nil
+}
+
+// GetLxcDns - hostname and resolver settings of a container.
+func (c *Client) GetLxcDns(vmr *VmRef) (dns *LxcDns, err error) {
+	vmr.SetVmType("lxc")
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return nil, err
+	}
+	return &LxcDns{
+		Hostname:      GetString(vmConfig, "hostname"),
+		Nameservers:   strings.Fields(GetString(vmConfig, "nameserver")),
+		SearchDomains: strings.Fields(GetString(vmConfig, "searchdomain")),
+	}, nil
+}
+
+// SetLxcDns - Set hostname and resolver settings of a container, empty lists are removed from the
+// config. An empty hostname is left unchanged.
+func (c *Client) SetLxcDns(vmr *VmRef, dns LxcDns) (err error) {
+	err = dns.Validate()
+	if err != nil {
+		return err
+	}
+	params := map[string]interface{}{}
+	if dns.Hostname != "" {
+		params["hostname"] = dns.Hostname
+	}
+	var deletes []string
+	if len(dns.Nameservers) > 0 {
+		params["nameserver"] = strings.Join(dns.Nameservers, " ")
+	} else {
+		deletes = append(deletes, "nameserver")
+	}
+	if len(dns.SearchDomains) > 0 {
+		params["searchdomain"] = strings.Join(dns.SearchDomains, " ")
+	} else {
+		deletes = append(deletes, "searchdomain")
+	}
+	if len(deletes) > 0 {
+		params["delete"] = strings.Join(deletes, ",")
+	}
+	vmr.SetVmType("lxc")
+	_, err = c.setGuestConfig(vmr, params)
+	return
+}
+
+// LxcResources - CPU and memory limits of a container. Cores 0 allows all the cores of the host,
+// CpuLimit 0 means no limit, Memory and Swap are in MB.
+type LxcResources struct {
+	Cores    int     `json:"cores"`
+	CpuLimit float64 `json:"cpulimit"`
+	CpuUnits int     `json:"cpuunits"`
+	Memory   int     `json:"memory"`
+	Swap     int     `json:"swap"`
+}
+
+// GetLxcResources - CPU and memory limits of a container.
+func (c *Client) GetLxcResources(vmr *VmRef) (resources *LxcResources, err error) {
+	vmr.SetVmType("lxc")
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return nil, err
+	}
+	return &LxcResources{
+		Cores:    GetIntDefault(vmConfig, "cores", 0),
+		CpuLimit: GetFloatDefault(vmConfig, "cpulimit", 0),
+		CpuUnits: GetIntDefault(vmConfig, "cpuunits", 0),
+		Memory:   GetIntDefault(vmConfig, "memory", 512),
+		Swap:     GetIntDefault(vmConfig, "swap", 512),
+	}, nil
+}
+
+// checkLxcCapacity - check cores and memory (MB) of a container against the capacity of its node, 0 skips a check.
+func (c *Client) checkLxcCapacity(vmr *VmRef, cores float64, memoryMB int) error {
+	status, err := c.GetNodeStatus(vmr.node)
+	if err != nil {
+		return err
+	}
+	if cpus := float64(status.CpuInfo.Cpus); cpus > 0 && cores > cpus {
+		return fmt.Errorf("%v cores requested for container %d but node %s has %v CPUs", cores, vmr.vmId, vmr.node, cpus)
+	}
+	if total := int64(status.Memory.Total); total > 0 && int64(memoryMB)*1024*1024 > total {
+		return fmt.Errorf("%d MB of memory requested for container %d but node %s has %d MB", memoryMB, vmr.vmId, vmr.node, total/(1024*1024))
+	}
+	return nil
+}
+
+// SetLxcCores - Set the number of cores of a container (0 for all cores of the host), applied live
+// to running containers.
+func (c *Client) SetLxcCores(vmr *VmRef, cores int) (err error) {
+	if cores < 0 || cores > 8192 {
+		return fmt.Errorf("cores %d out of range 0-8192", cores)
+	}
+	vmr.SetVmType("lxc")
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return err
+	}
+	if cores == 0 {
+		_, err = c.setGuestConfig(vmr, map[string]interface{}{"delete": "cores"})
+		return
+	}
+	err = c.checkLxcCapacity(vmr, float64(cores), 0)
+	if err != nil {
+		return err
+	}
+	_, err = c.setGuestConfig(vmr, map[string]interface{}{"cores": cores})
+	return
+}
+
+// SetLxcCpuLimit - Limit the CPU time of a container to limit cores (0 removes the limit), applied
+// live to running containers.
+func (c *Client) SetLxcCpuLimit(vmr *VmRef, limit float64) (err error) {
+	if limit < 0 || limit > 8192 {
+		return fmt.Errorf("cpulimit %v out of range 0-8192", limit)
+	}
+	vmr.SetVmType("lxc")
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return err
+	}
+	if limit == 0 {
+		_, err = c.setGuestConfig(vmr, map[string]interface{}{"delete": "cpulimit"})
+		return
+	}
+	err = c.checkLxcCapacity(vmr, limit, 0)
+	if err != nil {
+		return err
+	}
+	_, err = c.setGuestConfig(vmr, map[string]interface{}{"cpulimit": strconv.FormatFloat(limit, 'f', -1, 64)})
+	return
+}
+
+// SetLxcMemory - Set memory and swap (MB) of a container, applied live to running containers.
+// Shrinking below the memory in use fails on the node.
+func (c *Client) SetLxcMemory(vmr *VmRef, memoryMB int, swapMB int) (err error) {
+	if memoryMB < 16 {
+		return fmt.Errorf("memory must be at least 16 MB, got %d", memoryMB)
+	}
+	if swapMB < 0 {
+		return fmt.Errorf("swap must not be negative, got %d", swapMB)
+	}
+	vmr.SetVmType("lxc")
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return err
+	}
+	err = c.checkLxcCapacity(vmr, 0, memoryMB)
+	if err != nil {
+		return err
+	}
+	_, err = c.setGuestConfig(vmr, map[string]interface{}{"memory": memoryMB, "swap": swapMB})
+	return
+}
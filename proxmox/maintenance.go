@@ -0,0 +1,197 @@
+package proxmox
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaintenanceOptions - how MaintainNode evacuates a node.
+type MaintenanceOptions struct {
+	// Node receiving the guests, guests are shut down when empty.
+	MigrateTo string
+	// Live migrate running VMs (restart migration for containers).
+	Online bool
+	// How HA managed guests are released during the operations. Restore only applies to migrations,
+	// guests shut down get their HA resource back when restarted by RestoreGuests.
+	Ha HaOptions
+	// Called once the node is evacuated, e.g. to upgrade packages.
+	Maintenance func(node string) error
+	// Reboot the node once evacuated (after Maintenance) and wait for it, at most NodeTimeout.
+	RebootNode  bool
+	NodeTimeout time.Duration
+	// Migrate back or restart the guests once the maintenance is done.
+	RestoreGuests bool
+}
+
+// MaintenanceReport - guests handled by MaintainNode, by vmid.
+type MaintenanceReport struct {
+	Migrated []int
+	Shutdown []int
+	Restored []int
+	// Per guest failures, the other guests are still handled.
+	Failed map[int]error
+}
+
+type maintenanceGuest struct {
+	vmr     *VmRef
+	order   int
+	running bool
+	// HA resource of a guest shut down, as it was before being released.
+	haResource map[string]interface{}
+}
+
+// nodeGuests - non template guests of node in reverse startup order: guests without startup
+// order first, as Proxmox starts them last, then by decreasing order.
+func (c *Client) nodeGuests(node string) (guests []maintenanceGuest, err error) {
+	resources, err := c.GetVmResources()
+	if err != nil {
+		return nil, err
+	}
+	for _, resource := range resources {
+		if resource.Node != node || bool(resource.Template) {
+			continue
+		}
+		vmr := NewVmRef(int(resource.VmId))
+		vmr.SetNode(node)
+		vmr.SetVmType(resource.Type)
+		vmConfig, err := c.GetVmConfig(vmr)
+		if err != nil {
+			return nil, err
+		}
+		guest := maintenanceGuest{vmr: vmr, running: resource.Status == "running"}
+		if startup := GetString(vmConfig, "startup"); startup != "" {
+			guest.order = ParseQemuStartup(startup).Order
+		}
+		guests = append(guests, guest)
+	}
+	sort.SliceStable(guests, func(i, j int) bool {
+		if guests[i].order == 0 || guests[j].order == 0 {
+			return guests[i].order == 0 && guests[j].order != 0
+		}
+		return guests[i].order > guests[j].order
+	})
+	return
+}
+
+// RebootNode - Reboot node then wait for it to be back online, at most timeout.
+func (c *Client) RebootNode(node string, timeout time.Duration) (err error) {
+	reqbody := ParamsToBody(map[string]interface{}{"command": "reboot"})
+	_, err = c.session.Post(ApiPath("nodes", node, "status"), nil, nil, &reqbody)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(timeout)
+	wentDown := false
+	for time.Now().Before(deadline) {
+		time.Sleep(TaskStatusCheckInterval * time.Second)
+		nodes, err := c.getOnlineNodes()
+		if err != nil {
+			// The node answering the API may be the one rebooting.
+			continue
+		}
+		online := inArray(nodes, node)
+		if !online {
+			wentDown = true
+		} else if wentDown {
+			return nil
+		}
+	}
+	return fmt.Errorf("node %s not back online after %s", node, timeout)
+}
+
+// MaintainNode - Evacuate node for maintenance: guests are migrated to opts.MigrateTo, or shut
+// down cleanly, in reverse startup order. Then opts.Maintenance is called, the node is rebooted
+// when asked and the guests are migrated back or restarted in startup order.
+// A failing guest does not stop the workflow, failures are in the report and the returned error.
+func (c *Client) MaintainNode(node string, opts MaintenanceOptions) (report *MaintenanceReport, err error) {
+	guests, err := c.nodeGuests(node)
+	if err != nil {
+		return nil, err
+	}
+	report = &MaintenanceReport{Failed: map[int]error{}}
+	var evacuated []maintenanceGuest
+	for _, guest := range guests {
+		vmr := guest.vmr
+		if opts.MigrateTo != "" {
+			_, err = c.ManagedMigrateVm(vmr, opts.MigrateTo, opts.Online && guest.running, opts.Ha)
+			if err == nil {
+				report.Migrated = append(report.Migrated, vmr.vmId)
+			}
+		} else if guest.running {
+			// HA must not start the guest again on the node, it stays released until the guest
+			// is restarted.
+			guest.haResource, err = c.releaseHa(vmr, opts.Ha.Remove)
+			if err == nil {
+				_, err = c.ShutdownVm(vmr)
+				if err != nil && guest.haResource != nil {
+					// The guest stays on the node, HA manages it again.
+					c.restoreHa(vmr, guest.haResource, opts.Ha.Remove, "")
+				}
+			}
+			if err == nil {
+				report.Shutdown = append(report.Shutdown, vmr.vmId)
+			}
+		} else {
+			continue
+		}
+		if err != nil {
+			report.Failed[vmr.vmId] = err
+			continue
+		}
+		evacuated = append(evacuated, guest)
+	}
+
+	if len(report.Failed) == 0 {
+		if opts.Maintenance != nil {
+			err = opts.Maintenance(node)
+			if err != nil {
+				return report, fmt.Errorf("maintenance of node %s failed: %w", node, err)
+			}
+		}
+		if opts.RebootNode {
+			err = c.RebootNode(node, opts.NodeTimeout)
+			if err != nil {
+				return report, err
+			}
+		}
+	} else if opts.RebootNode {
+		// Rebooting would kill the guests left on the node.
+		return report, maintenanceError(node, "not rebooted, guests could not be evacuated", report.Failed)
+	}
+
+	if opts.RestoreGuests {
+		// Startup order, the reverse of the evacuation.
+		for i := len(evacuated) - 1; i >= 0; i-- {
+			guest := evacuated[i]
+			vmr := guest.vmr
+			if opts.MigrateTo != "" {
+				_, err = c.ManagedMigrateVm(vmr, node, opts.Online && guest.running, opts.Ha)
+			} else {
+				_, err = c.StartVm(vmr)
+				if err == nil && guest.haResource != nil {
+					err = c.restoreHa(vmr, guest.haResource, opts.Ha.Remove, "")
+				}
+			}
+			if err != nil {
+				report.Failed[vmr.vmId] = err
+				continue
+			}
+			report.Restored = append(report.Restored, vmr.vmId)
+		}
+	}
+	if len(report.Failed) > 0 {
+		return report, maintenanceError(node, "some guests failed", report.Failed)
+	}
+	return report, nil
+}
+
+func maintenanceError(node string, message string, failed map[int]error) error {
+	var details []string
+	for vmid, err := range failed {
+		details = append(details, fmt.Sprintf("%d: %s", vmid, err))
+	}
+	sort.Strings(details)
+	return fmt.Errorf("maintenance of node %s: %s (%s)", node, message, strings.Join(details, "; "))
+}
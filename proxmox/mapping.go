@@ -0,0 +1,97 @@
+package proxmox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DirMappingPath - host directory backing a directory mapping on one node.
+type DirMappingPath struct {
+	Node string
+	Path string
+}
+
+// DirMapping - cluster directory mapping, shared with guests through virtiofs (see QemuVirtiofs).
+type DirMapping struct {
+	Id          string
+	Description string
+	Paths       []DirMappingPath
+}
+
+func (c *Client) checkDirMappings() error {
+	caps, err := c.GetCapabilities()
+	if err != nil {
+		return err
+	}
+	if !caps.DirectoryMappings {
+		return errors.New("directory mappings require Proxmox VE 8.4 or later")
+	}
+	return nil
+}
+
+// GetDirMappings - List the cluster directory mappings.
+func (c *Client) GetDirMappings() (mappings []DirMapping, err error) {
+	err = c.checkDirMappings()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := getApiData[[]map[string]interface{}](c, "/cluster/mapping/dir")
+	if err != nil {
+		return nil, err
+	}
+	for _, mappingMap := range entries {
+		mapping := DirMapping{
+			Id:          GetString(mappingMap, "id"),
+			Description: GetString(mappingMap, "description"),
+		}
+		paths, _ := mappingMap["map"].([]interface{})
+		for _, path := range paths {
+			pathConf, _ := path.(string)
+			confMap := ParseConf(pathConf, ",", "=")
+			node, _ := confMap["node"].(string)
+			hostPath, _ := confMap["path"].(string)
+			mapping.Paths = append(mapping.Paths, DirMappingPath{Node: node, Path: hostPath})
+		}
+		mappings = append(mappings, mapping)
+	}
+	return
+}
+
+// CreateDirMapping - Add a cluster directory mapping, with one host path per node.
+func (c *Client) CreateDirMapping(mapping DirMapping) (err error) {
+	err = c.checkDirMappings()
+	if err != nil {
+		return err
+	}
+	if mapping.Id == "" || len(mapping.Paths) == 0 {
+		return errors.New("directory mapping needs an id and at least one path")
+	}
+	var paths []string
+	for _, path := range mapping.Paths {
+		if !strings.HasPrefix(path.Path, "/") {
+			return fmt.Errorf("directory mapping path '%s' is not absolute", path.Path)
+		}
+		paths = append(paths, fmt.Sprintf("node=%s,path=%s", path.Node, path.Path))
+	}
+	params := map[string]interface{}{
+		"id":  mapping.Id,
+		"map": paths,
+	}
+	if mapping.Description != "" {
+		params["description"] = mapping.Description
+	}
+	reqbody := ParamsToBody(params)
+	_, err = c.session.Post("/cluster/mapping/dir", nil, nil, &reqbody)
+	return
+}
+
+// DeleteDirMapping - Remove a cluster directory mapping.
+func (c *Client) DeleteDirMapping(id string) (err error) {
+	err = c.checkDirMappings()
+	if err != nil {
+		return err
+	}
+	_, err = c.session.Delete(ApiPath("cluster", "mapping", "dir", id), nil, nil)
+	return
+}
@@ -0,0 +1,84 @@
+package proxmox
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// MigrationDisk - disk of a VM stored on a storage local to its node.
+type MigrationDisk struct {
+	Volid      string   `json:"volid"`
+	DriveName  string   `json:"drivename"`
+	Size       FlexInt  `json:"size"`
+	Cdrom      FlexBool `json:"cdrom"`
+	IsUnused   FlexBool `json:"is_unused"`
+	IsVmState  FlexBool `json:"is_vmstate"`
+	Replicated FlexBool `json:"replicated"`
+}
+
+// MigrationNodeBlockers - why a VM cannot migrate to a node: storages of its disks that the node
+// lacks, and devices (hostpciN, usbN) whose mapping has no entry on the node.
+type MigrationNodeBlockers struct {
+	UnavailableStorages  []string `json:"unavailable_storages"`
+	UnavailableResources []string `json:"unavailable-resources"`
+}
+
+// MigrationPreconditions - migration checks of a VM. LocalResources are devices tied to its node
+// (passthrough, local CD images...), which block migration unless mapped (MappedResources).
+// LocalDisks are copied by the migration, live migrations included (with-local-disks).
+type MigrationPreconditions struct {
+	Running         FlexBool                         `json:"running"`
+	AllowedNodes    []string                         `json:"allowed_nodes"`
+	NotAllowedNodes map[string]MigrationNodeBlockers `json:"not_allowed_nodes"`
+	LocalDisks      []MigrationDisk                  `json:"local_disks"`
+	LocalResources  []string                         `json:"local_resources"`
+	MappedResources []string                         `json:"mapped-resources"`
+}
+
+// Blockers - reasons the migration to target would fail, empty when it can be attempted.
+func (preconditions MigrationPreconditions) Blockers(target string) (blockers []string) {
+	mapped := map[string]bool{}
+	for _, resource := range preconditions.MappedResources {
+		mapped[resource] = true
+	}
+	for _, resource := range preconditions.LocalResources {
+		if !mapped[resource] {
+			blockers = append(blockers, fmt.Sprintf("local resource '%s'", resource))
+		}
+	}
+	if nodeBlockers, notAllowed := preconditions.NotAllowedNodes[target]; notAllowed {
+		for _, storage := range nodeBlockers.UnavailableStorages {
+			blockers = append(blockers, fmt.Sprintf("storage '%s' is not available on node '%s'", storage, target))
+		}
+		for _, resource := range nodeBlockers.UnavailableResources {
+			blockers = append(blockers, fmt.Sprintf("resource '%s' is not mapped on node '%s'", resource, target))
+		}
+	}
+	return
+}
+
+// AllowedTargets - nodes the VM can be migrated to, sorted.
+func (preconditions MigrationPreconditions) AllowedTargets() []string {
+	targets := append([]string{}, preconditions.AllowedNodes...)
+	sort.Strings(targets)
+	return targets
+}
+
+// GetMigrationPreconditions - Check whether a VM can be migrated, to target when set (empty checks
+// every node). Only QEMU VMs have migration preconditions.
+func (c *Client) GetMigrationPreconditions(vmr *VmRef, target string) (preconditions *MigrationPreconditions, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return nil, err
+	}
+	if vmr.vmType != "qemu" {
+		return nil, errors.New("migration preconditions are only available for QEMU VMs")
+	}
+	params := url.Values{}
+	if target != "" {
+		params.Set("target", target)
+	}
+	return getApiDataWithParams[*MigrationPreconditions](c, ApiPath("nodes", vmr.node, "qemu", vmr.vmId, "migrate"), params)
+}
@@ -0,0 +1,171 @@
+package proxmox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NetstatEntry - traffic counters of a guest network interface, In and Out are bytes.
+type NetstatEntry struct {
+	VmId FlexInt `json:"vmid"`
+	Dev  string  `json:"dev"`
+	In   FlexInt `json:"in"`
+	Out  FlexInt `json:"out"`
+}
+
+// GetNodeReport - Get the system report of node (`pvereport`), a large text bundle for support.
+// Generating it can take minutes, the transfer timeout applies.
+func (c *Client) GetNodeReport(node string) (report string, err error) {
+	return getApiData[string](c.WithTimeout(c.session.TransferTimeout), ApiPath("nodes", node, "report"))
+}
+
+// GetNodeNetstat - Get the traffic counters of the guest network interfaces running on node.
+func (c *Client) GetNodeNetstat(node string) (netstat []NetstatEntry, err error) {
+	return getApiData[[]NetstatEntry](c, ApiPath("nodes", node, "netstat"))
+}
+
+// CpuModel - CPU model QEMU supports on a node, Custom models are defined in /etc/pve/virtual-guest/cpu-models.conf.
+type CpuModel struct {
+	Name   string   `json:"name"`
+	Vendor string   `json:"vendor"`
+	Custom FlexBool `json:"custom"`
+}
+
+// MachineVersion - machine type QEMU supports on a node, e.g. Id `pc-q35-8.1` of Type `q35`.
+type MachineVersion struct {
+	Id      string `json:"id"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+}
+
+// NodeCapabilities - CPU models and machine types supported by the QEMU of a node.
+type NodeCapabilities struct {
+	CpuModels []CpuModel
+	Machines  []MachineVersion
+}
+
+// SupportsCpu - is the cpu option value (`host`, `x86-64-v2-AES,flags=+aes`, `cputype=kvm64`) supported?
+func (caps NodeCapabilities) SupportsCpu(cpu string) bool {
+	name := strings.TrimPrefix(strings.Split(cpu, ",")[0], "cputype=")
+	for _, model := range caps.CpuModels {
+		if model.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsMachine - is the machine option value (`q35`, `pc-i440fx-8.1`, `q35,viommu=intel`) supported?
+// Unversioned families are supported when any of their versions is.
+func (caps NodeCapabilities) SupportsMachine(machine string) bool {
+	machineType := strings.Split(machine, ",")[0]
+	if machineType == "" {
+		return true
+	}
+	for _, version := range caps.Machines {
+		if version.Id == machineType || version.Type == machineType || (machineType == "pc" && version.Type == MachineI440fx) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckConfig - check the machine type of config against the node, cpu is the cpu option value
+// to check as well (empty to skip).
+func (caps NodeCapabilities) CheckConfig(config ConfigQemu, cpu string) error {
+	if config.QemuMachine != "" && !caps.SupportsMachine(config.QemuMachine) {
+		return fmt.Errorf("machine type '%s' is not supported by the node", config.QemuMachine)
+	}
+	if cpu != "" && !caps.SupportsCpu(cpu) {
+		return fmt.Errorf("CPU model '%s' is not supported by the node", cpu)
+	}
+	return nil
+}
+
+// GetNodeCapabilities - Get the CPU models and machine types supported on node.
+func (c *Client) GetNodeCapabilities(node string) (caps *NodeCapabilities, err error) {
+	caps = &NodeCapabilities{}
+	caps.CpuModels, err = getApiData[[]CpuModel](c, ApiPath("nodes", node, "capabilities", "qemu", "cpu"))
+	if err != nil {
+		return nil, err
+	}
+	caps.Machines, err = getApiData[[]MachineVersion](c, ApiPath("nodes", node, "capabilities", "qemu", "machines"))
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// NodeMemory - memory or swap counters of a node, in bytes.
+type NodeMemory struct {
+	Total FlexInt `json:"total"`
+	Used  FlexInt `json:"used"`
+	Free  FlexInt `json:"free"`
+}
+
+// NodeStatus - current status of a node, Cpu is the utilization between 0 and 1.
+type NodeStatus struct {
+	CpuInfo struct {
+		Cpus    FlexInt   `json:"cpus"`
+		Cores   FlexInt   `json:"cores"`
+		Sockets FlexInt   `json:"sockets"`
+		Model   string    `json:"model"`
+		Mhz     FlexFloat `json:"mhz"`
+	} `json:"cpuinfo"`
+	Cpu        FlexFloat  `json:"cpu"`
+	Wait       FlexFloat  `json:"wait"`
+	LoadAvg    []string   `json:"loadavg"`
+	Memory     NodeMemory `json:"memory"`
+	Swap       NodeMemory `json:"swap"`
+	Uptime     FlexInt    `json:"uptime"`
+	PveVersion string     `json:"pveversion"`
+}
+
+// GetNodeStatus - Get the current CPU, memory and swap status of node.
+func (c *Client) GetNodeStatus(node string) (status *NodeStatus, err error) {
+	return getApiData[*NodeStatus](c, ApiPath("nodes", node, "status"))
+}
+
+// NodeTime - clock of a node, Time is the UTC epoch and LocalTime the epoch shifted by the
+// offset of Timezone (e.g. `Europe/Paris`).
+type NodeTime struct {
+	Time      FlexInt `json:"time"`
+	LocalTime FlexInt `json:"localtime"`
+	Timezone  string  `json:"timezone"`
+}
+
+// GetNodeTime - Get the time and timezone of node.
+func (c *Client) GetNodeTime(node string) (nodeTime *NodeTime, err error) {
+	return getApiData[*NodeTime](c, ApiPath("nodes", node, "time"))
+}
+
+// SetNodeTimezone - Set the timezone of node, a tz database name like `Europe/Paris` or `UTC`.
+func (c *Client) SetNodeTimezone(node string, timezone string) (err error) {
+	reqbody := ParamsToBody(map[string]interface{}{"timezone": timezone})
+	_, err = c.session.Put(ApiPath("nodes", node, "time"), nil, nil, &reqbody)
+	return
+}
+
+// SetClusterTimezone - Set the timezone of every online node not already using it, returns the
+// nodes changed.
+func (c *Client) SetClusterTimezone(timezone string) (changed []string, err error) {
+	nodes, err := c.getOnlineNodes()
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		nodeTime, err := c.GetNodeTime(node)
+		if err != nil {
+			return changed, err
+		}
+		if nodeTime.Timezone == timezone {
+			continue
+		}
+		err = c.SetNodeTimezone(node, timezone)
+		if err != nil {
+			return changed, err
+		}
+		changed = append(changed, node)
+	}
+	return
+}
@@ -0,0 +1,47 @@
+package proxmox
+
+import (
+	"errors"
+)
+
+// NotificationTarget - endpoint notifications are delivered to, Type is sendmail, smtp, gotify
+// or webhook and Origin is builtin, user-created or modified-builtin.
+type NotificationTarget struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Comment string   `json:"comment"`
+	Disable FlexBool `json:"disable"`
+	Origin  string   `json:"origin"`
+}
+
+// checkNotificationSupport - notification targets exist since PVE 8.1.
+func (c *Client) checkNotificationSupport() error {
+	caps, err := c.GetCapabilities()
+	if err != nil {
+		return err
+	}
+	if !caps.NotificationTarget {
+		return errors.New("notification targets need PVE 8.1 or later")
+	}
+	return nil
+}
+
+// ListNotificationTargets - Notification targets of the cluster, whatever their type (PVE 8.1+).
+func (c *Client) ListNotificationTargets() (targets []NotificationTarget, err error) {
+	err = c.checkNotificationSupport()
+	if err != nil {
+		return nil, err
+	}
+	return getApiData[[]NotificationTarget](c, "/cluster/notifications/targets")
+}
+
+// TestNotificationTarget - Send a test notification through target name (PVE 8.1+). The error
+// reports why the notification could not be delivered, e.g. a refused SMTP login.
+func (c *Client) TestNotificationTarget(name string) (err error) {
+	err = c.checkNotificationSupport()
+	if err != nil {
+		return err
+	}
+	_, err = c.session.Post(ApiPath("cluster", "notifications", "targets", name, "test"), nil, nil, nil)
+	return
+}
@@ -0,0 +1,104 @@
+package proxmox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TaskNotificationTimeout - timeout of a TaskWebhookUrl call.
+const TaskNotificationTimeout = 10 * time.Second
+
+// TaskCompletion - task finished, or given up on, by WaitForCompletion. Type is the task type
+// (qmstart, qmclone, vzdump...), Id the guest id or other object of the task, Duration the time
+// since the task started. Err is set when the task could not be waited on (e.g. ErrTaskTimeout),
+// ExitStatus is then empty.
+type TaskCompletion struct {
+	Upid       string        `json:"upid"`
+	Node       string        `json:"node"`
+	Type       string        `json:"type"`
+	Id         string        `json:"id"`
+	User       string        `json:"user"`
+	ExitStatus string        `json:"exitstatus"`
+	Duration   time.Duration `json:"-"`
+	Err        error         `json:"-"`
+}
+
+// Success - whether the task ended successfully.
+func (task TaskCompletion) Success() bool {
+	return task.Err == nil && task.ExitStatus == exitStatusSuccess
+}
+
+// MarshalJSON - webhook payload, with the duration in seconds and the error message.
+func (task TaskCompletion) MarshalJSON() ([]byte, error) {
+	type completion TaskCompletion
+	payload := struct {
+		completion
+		Duration float64 `json:"duration"`
+		Success  bool    `json:"success"`
+		Error    string  `json:"error,omitempty"`
+	}{completion: completion(task), Duration: task.Duration.Seconds(), Success: task.Success()}
+	if task.Err != nil {
+		payload.Error = task.Err.Error()
+	}
+	return json.Marshal(payload)
+}
+
+// newTaskCompletion - completion of task upid, which is
+// `UPID:node:pid:pstart:starttime:type:id:user:` with hexadecimal pid, pstart and starttime.
+func newTaskCompletion(upid string, waitStart time.Time, exitStatus string, err error) TaskCompletion {
+	task := TaskCompletion{Upid: upid, ExitStatus: exitStatus, Err: err, Duration: time.Since(waitStart)}
+	parts := strings.Split(upid, ":")
+	if len(parts) < 8 {
+		return task
+	}
+	task.Node, task.Type, task.Id, task.User = parts[1], parts[5], parts[6], parts[7]
+	startTime, parseErr := strconv.ParseInt(parts[4], 16, 64)
+	if parseErr == nil {
+		// The wait started after the task, keep it when the node clock is ahead.
+		duration := time.Since(time.Unix(startTime, 0))
+		if duration > task.Duration {
+			task.Duration = duration
+		}
+	}
+	return task
+}
+
+// notifyTaskCompletion - call the TaskCompleted callback and post to the TaskWebhookUrl of the
+// configuration. The webhook is called in the background, its failures are reported to
+// TaskWebhookError and do not change the outcome of the task.
+func (c *Client) notifyTaskCompletion(task TaskCompletion) {
+	if c.configuration.TaskCompleted != nil {
+		c.configuration.TaskCompleted(task)
+	}
+	if c.configuration.TaskWebhookUrl == "" {
+		return
+	}
+	go func() {
+		err := postTaskCompletion(c.configuration.TaskWebhookUrl, task)
+		if err != nil && c.configuration.TaskWebhookError != nil {
+			c.configuration.TaskWebhookError(task, err)
+		}
+	}()
+}
+
+func postTaskCompletion(webhookUrl string, task TaskCompletion) error {
+	body, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	httpClient := &http.Client{Timeout: TaskNotificationTimeout}
+	resp, err := httpClient.Post(webhookUrl, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("task webhook answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package proxmox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// OpenID Connect login: OpenIdAuthUrl returns the URL of the identity provider the user
+// must open, the provider then redirects the browser to redirectUrl with `code` and `state`
+// query parameters which OpenIdLogin exchanges for a ticket:
+//
+//	authUrl, _ := client.OpenIdAuthUrl("sso", "https://app.example.com/callback")
+//	// ... user authenticates, the callback receives https://app.example.com/callback?code=...&state=...
+//	err := client.OpenIdLoginFromCallback(authUrl, "https://app.example.com/callback", callbackUrl)
+
+// OpenIdAuthUrl - Get the identity provider URL starting the OIDC login on realm.
+func (c *Client) OpenIdAuthUrl(realm string, redirectUrl string) (authUrl string, err error) {
+	reqbody := ParamsToBody(map[string]interface{}{
+		"realm":        realm,
+		"redirect-url": redirectUrl,
+	})
+	resp, err := c.session.Post("/access/openid/auth-url", nil, nil, &reqbody)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var response ApiResponse[string]
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return "", err
+	}
+	if response.Data == "" {
+		return "", errors.New("OpenID auth URL not readable")
+	}
+	return response.Data, response.Err()
+}
+
+// OpenIdLogin - Exchange the code and state received on redirectUrl for a ticket, the session
+// is then authenticated as the OIDC user.
+func (c *Client) OpenIdLogin(redirectUrl string, code string, state string) (err error) {
+	reqbody := ParamsToBody(map[string]interface{}{
+		"code":         code,
+		"state":        state,
+		"redirect-url": redirectUrl,
+	})
+	olddebug := *Debug
+	*Debug = false // don't share tickets in debug log
+	resp, err := c.session.Post("/access/openid/login", nil, nil, &reqbody)
+	*Debug = olddebug
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var response ApiResponse[map[string]interface{}]
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return err
+	}
+	if err = response.Err(); err != nil {
+		return err
+	}
+	return c.session.setTicket(response.Data)
+}
+
+// OpenIdLoginFromCallback - OpenIdLogin with the code and state of the URL the identity provider
+// redirected to. The state must be the one of authUrl, protecting against forged callbacks.
+func (c *Client) OpenIdLoginFromCallback(authUrl string, redirectUrl string, callbackUrl string) (err error) {
+	code, state, err := ParseOpenIdCallback(authUrl, callbackUrl)
+	if err != nil {
+		return err
+	}
+	return c.OpenIdLogin(redirectUrl, code, state)
+}
+
+// ParseOpenIdCallback - code and state of the callback URL, checking the state against the one of authUrl.
+func ParseOpenIdCallback(authUrl string, callbackUrl string) (code string, state string, err error) {
+	auth, err := url.Parse(authUrl)
+	if err != nil {
+		return "", "", err
+	}
+	callback, err := url.Parse(callbackUrl)
+	if err != nil {
+		return "", "", err
+	}
+	query := callback.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		return "", "", fmt.Errorf("OpenID login refused: %s %s", providerErr, query.Get("error_description"))
+	}
+	code, state = query.Get("code"), query.Get("state")
+	if code == "" || state == "" {
+		return "", "", errors.New("OpenID callback has no code or state")
+	}
+	if expected := auth.Query().Get("state"); expected != "" && expected != state {
+		return "", "", errors.New("OpenID callback state does not match the login request")
+	}
+	return
+}
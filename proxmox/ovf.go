@@ -0,0 +1,177 @@
+package proxmox
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// OVF hardware item resource types (CIM_ResourceAllocationSettingData).
+const (
+	ovfResourceCpu      = 3
+	ovfResourceMemory   = 4
+	ovfResourceEthernet = 10
+	ovfResourceDisk     = 17
+)
+
+// OvfDescriptor - the parts of an OVF envelope needed to rebuild the VM on Proxmox.
+type OvfDescriptor struct {
+	Name     string
+	Cores    int
+	MemoryMB int
+	Nics     int
+	// Disk files in controller order, as referenced by the descriptor.
+	DiskFiles []string
+}
+
+type ovfEnvelope struct {
+	References []struct {
+		Id   string `xml:"id,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"References>File"`
+	Disks []struct {
+		DiskId  string `xml:"diskId,attr"`
+		FileRef string `xml:"fileRef,attr"`
+	} `xml:"DiskSection>Disk"`
+	VirtualSystem struct {
+		Id    string `xml:"id,attr"`
+		Name  string `xml:"Name"`
+		Items []struct {
+			ResourceType    int    `xml:"ResourceType"`
+			VirtualQuantity int    `xml:"VirtualQuantity"`
+			AllocationUnits string `xml:"AllocationUnits"`
+			HostResource    string `xml:"HostResource"`
+		} `xml:"VirtualHardwareSection>Item"`
+	} `xml:"VirtualSystem"`
+}
+
+// ParseOvf - read an OVF descriptor.
+func ParseOvf(ovf io.Reader) (descriptor *OvfDescriptor, err error) {
+	var envelope ovfEnvelope
+	err = xml.NewDecoder(ovf).Decode(&envelope)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]string{}
+	for _, file := range envelope.References {
+		files[file.Id] = file.Href
+	}
+	disks := map[string]string{}
+	for _, disk := range envelope.Disks {
+		disks[disk.DiskId] = files[disk.FileRef]
+	}
+
+	system := envelope.VirtualSystem
+	descriptor = &OvfDescriptor{Name: system.Name, Cores: 1}
+	if descriptor.Name == "" {
+		descriptor.Name = system.Id
+	}
+	for _, item := range system.Items {
+		switch item.ResourceType {
+		case ovfResourceCpu:
+			// Descriptors may leave the count out, a single core is assumed then.
+			if item.VirtualQuantity > 0 {
+				descriptor.Cores = item.VirtualQuantity
+			}
+		case ovfResourceMemory:
+			descriptor.MemoryMB = ovfMemoryMB(item.VirtualQuantity, item.AllocationUnits)
+		case ovfResourceEthernet:
+			descriptor.Nics++
+		case ovfResourceDisk:
+			// HostResource is `ovf:/disk/<diskId>`.
+			diskId := item.HostResource[strings.LastIndex(item.HostResource, "/")+1:]
+			file, ok := disks[diskId]
+			if !ok || file == "" {
+				return nil, fmt.Errorf("OVF disk '%s' has no file reference", item.HostResource)
+			}
+			descriptor.DiskFiles = append(descriptor.DiskFiles, file)
+		}
+	}
+	if descriptor.MemoryMB == 0 {
+		return nil, errors.New("OVF descriptor has no memory definition")
+	}
+	return
+}
+
+// ovfMemoryMB - convert a memory quantity to MB, units are like `byte * 2^20` or `MegaBytes`.
+func ovfMemoryMB(quantity int, units string) int {
+	units = strings.ToLower(strings.Replace(units, " ", "", -1))
+	switch {
+	case units == "byte*2^30" || strings.HasPrefix(units, "gigabyte"):
+		return quantity * 1024
+	case units == "byte*2^10" || strings.HasPrefix(units, "kilobyte"):
+		return quantity / 1024
+	case units == "byte":
+		return quantity / (1024 * 1024)
+	default:
+		return quantity
+	}
+}
+
+// ImportOvf - Create a VM on node from an OVF descriptor and import its disks to storage.
+// The disk files must already be reachable by the node, sourcePrefix is prepended to every
+// file referenced by the descriptor (e.g. `local:import/appliance/` or `/mnt/ovf/`).
+// Network interfaces are attached to bridge with the virtio model.
+func (c *Client) ImportOvf(node string, storage string, ovf io.Reader, sourcePrefix string, bridge string) (vmr *VmRef, err error) {
+	descriptor, err := ParseOvf(ovf)
+	if err != nil {
+		return nil, err
+	}
+	if len(descriptor.DiskFiles) > 0 {
+		caps, err := c.GetCapabilities()
+		if err != nil {
+			return nil, err
+		}
+		if !caps.ImportFrom {
+			return nil, errors.New("import-from requires Proxmox VE 7.2 or later")
+		}
+	}
+	nextid, err := c.GetNextID(0)
+	if err != nil {
+		return nil, err
+	}
+	vmr = NewVmRef(nextid)
+	vmr.SetNode(node)
+	vmr.SetVmType("qemu")
+
+	params := map[string]interface{}{
+		"vmid":    vmr.vmId,
+		"name":    descriptor.Name,
+		"cores":   descriptor.Cores,
+		"sockets": 1,
+		"memory":  descriptor.MemoryMB,
+		"scsihw":  ScsiHwVirtio,
+	}
+	for diskID, file := range descriptor.DiskFiles {
+		params["scsi"+strconv.Itoa(diskID)] = fmt.Sprintf("%s:0,import-from=%s%s", storage, sourcePrefix, file)
+	}
+	if len(descriptor.DiskFiles) > 0 {
+		params["boot"] = "order=scsi0"
+	}
+	for nicID := 0; nicID < descriptor.Nics; nicID++ {
+		params["net"+strconv.Itoa(nicID)] = "virtio,bridge=" + bridge
+	}
+
+	exitStatus, err := c.CreateQemuVm(node, params)
+	if err != nil {
+		return nil, err
+	}
+	if exitStatus != exitStatusSuccess {
+		return nil, fmt.Errorf("OVF import of '%s' failed: %s", descriptor.Name, exitStatus)
+	}
+	return
+}
+
+// ImportOvfFile - ImportOvf reading the descriptor from a local file.
+func (c *Client) ImportOvfFile(node string, storage string, ovfPath string, sourcePrefix string, bridge string) (vmr *VmRef, err error) {
+	file, err := os.Open(ovfPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return c.ImportOvf(node, storage, file, sourcePrefix, bridge)
+}
@@ -0,0 +1,90 @@
+package proxmox
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func ovfFixture(items string) string {
+	return `<?xml version="1.0"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1"
+ xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData">
+  <References>
+    <File ovf:id="file1" ovf:href="appliance-disk1.vmdk"/>
+    <File ovf:id="file2" ovf:href="appliance-disk2.vmdk"/>
+  </References>
+  <DiskSection>
+    <Disk ovf:diskId="vmdisk1" ovf:fileRef="file1"/>
+    <Disk ovf:diskId="vmdisk2" ovf:fileRef="file2"/>
+  </DiskSection>
+  <VirtualSystem ovf:id="appliance">
+    <VirtualHardwareSection>` + items + `
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>`
+}
+
+func TestParseOvf(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		items   string
+		want    *OvfDescriptor
+		wantErr bool
+	}{
+		{
+			name: "full",
+			items: `
+      <Item><rasd:ResourceType>3</rasd:ResourceType><rasd:VirtualQuantity>4</rasd:VirtualQuantity></Item>
+      <Item><rasd:ResourceType>4</rasd:ResourceType><rasd:AllocationUnits>byte * 2^20</rasd:AllocationUnits><rasd:VirtualQuantity>2048</rasd:VirtualQuantity></Item>
+      <Item><rasd:ResourceType>10</rasd:ResourceType></Item>
+      <Item><rasd:ResourceType>10</rasd:ResourceType></Item>
+      <Item><rasd:ResourceType>17</rasd:ResourceType><rasd:HostResource>ovf:/disk/vmdisk2</rasd:HostResource></Item>
+      <Item><rasd:ResourceType>17</rasd:ResourceType><rasd:HostResource>ovf:/disk/vmdisk1</rasd:HostResource></Item>`,
+			want: &OvfDescriptor{Name: "appliance", Cores: 4, MemoryMB: 2048, Nics: 2, DiskFiles: []string{"appliance-disk2.vmdk", "appliance-disk1.vmdk"}},
+		},
+		{
+			name: "memory in gigabytes and default core",
+			items: `
+      <Item><rasd:ResourceType>3</rasd:ResourceType></Item>
+      <Item><rasd:ResourceType>4</rasd:ResourceType><rasd:AllocationUnits>GigaBytes</rasd:AllocationUnits><rasd:VirtualQuantity>2</rasd:VirtualQuantity></Item>`,
+			want: &OvfDescriptor{Name: "appliance", Cores: 1, MemoryMB: 2048},
+		},
+		{
+			name: "no memory",
+			items: `
+      <Item><rasd:ResourceType>3</rasd:ResourceType><rasd:VirtualQuantity>2</rasd:VirtualQuantity></Item>`,
+			wantErr: true,
+		},
+		{
+			name: "unknown disk",
+			items: `
+      <Item><rasd:ResourceType>4</rasd:ResourceType><rasd:VirtualQuantity>512</rasd:VirtualQuantity></Item>
+      <Item><rasd:ResourceType>17</rasd:ResourceType><rasd:HostResource>ovf:/disk/vmdisk3</rasd:HostResource></Item>`,
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseOvf(strings.NewReader(ovfFixture(test.items)))
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseOvfInvalid(t *testing.T) {
+	_, err := ParseOvf(strings.NewReader("not xml"))
+	if err == nil {
+		t.Fatal("expected an error for a descriptor that is not XML")
+	}
+}
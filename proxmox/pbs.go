@@ -0,0 +1,170 @@
+package proxmox
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Proxmox VE lists the backups of a Proxmox Backup Server storage with their verification
+// state but cannot start a verification, which is requested from the backup server itself
+// with a client from NewPbsClient.
+
+// Verification states of backup snapshots.
+const (
+	VerificationOk     = "ok"
+	VerificationFailed = "failed"
+)
+
+// BackupVerification - last verification of a backup snapshot.
+type BackupVerification struct {
+	State string `json:"state"`
+	Upid  string `json:"upid"`
+}
+
+// PbsBackup - backup of a PBS storage, Verification is nil when it was never verified.
+type PbsBackup struct {
+	Volid        string              `json:"volid"`
+	VmId         FlexInt             `json:"vmid"`
+	CTime        FlexInt             `json:"ctime"`
+	Size         FlexInt             `json:"size"`
+	Protected    FlexBool            `json:"protected"`
+	Notes        string              `json:"notes"`
+	Verification *BackupVerification `json:"verification"`
+}
+
+// Verified - whether the last verification of the backup succeeded.
+func (backup PbsBackup) Verified() bool {
+	return backup.Verification != nil && backup.Verification.State == VerificationOk
+}
+
+// PbsSnapshot - backup snapshot as named by the backup server, Type is vm, ct or host.
+type PbsSnapshot struct {
+	Type string
+	Id   string
+	Time time.Time
+}
+
+// ParsePbsVolid - snapshot of a PBS volid like `pbs:backup/vm/100/2024-05-01T10:00:00Z`.
+func ParsePbsVolid(volid string) (snapshot PbsSnapshot, err error) {
+	_, volumeName := getStorageAndVolumeName(volid, ":")
+	parts := strings.Split(volumeName, "/")
+	if len(parts) != 4 || parts[0] != "backup" {
+		return snapshot, fmt.Errorf("'%s' is not a PBS backup volid", volid)
+	}
+	snapshot.Type, snapshot.Id = parts[1], parts[2]
+	snapshot.Time, err = time.Parse(time.RFC3339, parts[3])
+	if err != nil {
+		return snapshot, fmt.Errorf("'%s' is not a PBS backup volid: %s", volid, err)
+	}
+	return
+}
+
+// pbsStorage - datastore and namespace of PBS storage.
+func (c *Client) pbsStorage(storage string) (datastore string, namespace string, err error) {
+	storageConfig, err := getApiData[map[string]interface{}](c, ApiPath("storage", storage))
+	if err != nil {
+		return "", "", err
+	}
+	if GetString(storageConfig, "type") != "pbs" {
+		return "", "", fmt.Errorf("storage '%s' is not a Proxmox Backup Server storage", storage)
+	}
+	return GetString(storageConfig, "datastore"), GetString(storageConfig, "namespace"), nil
+}
+
+// GetPbsBackups - Backups of PBS storage seen from node with their verification state,
+// optionally filtered by owner vmid (0 lists all).
+func (c *Client) GetPbsBackups(node string, storage string, vmid int) (backups []PbsBackup, err error) {
+	_, _, err = c.pbsStorage(storage)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{"content": {"backup"}}
+	if vmid > 0 {
+		params.Set("vmid", strconv.Itoa(vmid))
+	}
+	return getApiDataWithParams[[]PbsBackup](c, ApiPath("nodes", node, "storage", storage, "content"), params)
+}
+
+// GetPbsBackupVerification - Verification state of the backup volid, nil when it was never verified.
+func (c *Client) GetPbsBackupVerification(node string, volid string) (verification *BackupVerification, err error) {
+	snapshot, err := ParsePbsVolid(volid)
+	if err != nil {
+		return nil, err
+	}
+	// Only listings report verifications, narrowed to the owner of guest backups.
+	vmid, _ := strconv.Atoi(snapshot.Id)
+	storage, _ := getStorageAndVolumeName(volid, ":")
+	backups, err := c.GetPbsBackups(node, storage, vmid)
+	if err != nil {
+		return nil, err
+	}
+	for _, backup := range backups {
+		if backup.Volid == volid {
+			return backup.Verification, nil
+		}
+	}
+	return nil, &ErrNotFound{fmt.Sprintf("backup '%s' not found", volid)}
+}
+
+// NewPbsClient - client of the Proxmox Backup Server API at pbsUrl (`https://pbs:8007/api2/json`),
+// authenticated with an API token (`user@realm!name` and its secret). Only the PBS specific
+// methods and task waits (WaitForCompletion) apply to it.
+func NewPbsClient(pbsUrl string, tokenId string, tokenSecret string, tlsInsecure bool) (client *Client, err error) {
+	return NewClient(&Configuration{
+		Url:         pbsUrl,
+		TlsInsecure: tlsInsecure,
+		Headers:     http.Header{"Authorization": {"PBSAPIToken=" + tokenId + ":" + tokenSecret}},
+	}, false)
+}
+
+func pbsSnapshotParams(namespace string, snapshot PbsSnapshot) map[string]interface{} {
+	params := map[string]interface{}{
+		"backup-type": snapshot.Type,
+		"backup-id":   snapshot.Id,
+		"backup-time": snapshot.Time.Unix(),
+	}
+	if namespace != "" {
+		params["ns"] = namespace
+	}
+	return params
+}
+
+// VerifyPbsSnapshot - Verify a snapshot of datastore, on a client from NewPbsClient.
+// Returns the exit status of the verification task, the snapshot is then flagged ok or failed.
+func (c *Client) VerifyPbsSnapshot(datastore string, namespace string, snapshot PbsSnapshot) (exitStatus string, err error) {
+	reqbody := ParamsToBody(pbsSnapshotParams(namespace, snapshot))
+	url := ApiPath("admin", "datastore", datastore, "verify")
+	resp, err := c.session.Post(url, nil, nil, &reqbody)
+	if err == nil {
+		taskResponse := ResponseJSON(resp)
+		exitStatus, err = c.WaitForCompletion(taskResponse)
+	}
+	return
+}
+
+// VerifyPbsBackup - Verify the backup volid of a PBS storage through pbs, a client from
+// NewPbsClient for the server of the storage, and return its new verification state.
+func (c *Client) VerifyPbsBackup(pbs *Client, node string, volid string) (verification *BackupVerification, err error) {
+	storage, _ := getStorageAndVolumeName(volid, ":")
+	datastore, namespace, err := c.pbsStorage(storage)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := ParsePbsVolid(volid)
+	if err != nil {
+		return nil, err
+	}
+	_, err = pbs.VerifyPbsSnapshot(datastore, namespace, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	verification, err = c.GetPbsBackupVerification(node, volid)
+	if err == nil && verification == nil {
+		return nil, &ErrUnexpectedResponse{"backup has no verification state", volid}
+	}
+	return
+}
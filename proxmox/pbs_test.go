@@ -0,0 +1,37 @@
+package proxmox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePbsVolid(t *testing.T) {
+	for _, test := range []struct {
+		volid   string
+		want    PbsSnapshot
+		wantErr bool
+	}{
+		{"pbs:backup/vm/100/2024-05-01T10:00:00Z", PbsSnapshot{"vm", "100", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}, false},
+		{"backup-server:backup/ct/201/2023-12-31T23:59:59Z", PbsSnapshot{"ct", "201", time.Date(2023, 12, 31, 23, 59, 59, 0, time.UTC)}, false},
+		{"pbs:backup/host/pve1/2024-01-02T03:04:05Z", PbsSnapshot{"host", "pve1", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}, false},
+		{"local:backup/vzdump-qemu-100-2024_05_01-10_00_00.vma.zst", PbsSnapshot{}, true},
+		{"pbs:backup/vm/100", PbsSnapshot{}, true},
+		{"pbs:images/vm/100/2024-05-01T10:00:00Z", PbsSnapshot{}, true},
+		{"pbs:backup/vm/100/yesterday", PbsSnapshot{}, true},
+	} {
+		got, err := ParsePbsVolid(test.volid)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParsePbsVolid(%q): expected an error, got %+v", test.volid, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePbsVolid(%q): %s", test.volid, err)
+			continue
+		}
+		if got.Type != test.want.Type || got.Id != test.want.Id || !got.Time.Equal(test.want.Time) {
+			t.Errorf("ParsePbsVolid(%q) = %+v, want %+v", test.volid, got, test.want)
+		}
+	}
+}
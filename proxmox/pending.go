@@ -0,0 +1,154 @@
+package proxmox
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ConfigValueState - whether a config value is applied, waiting to be applied or waiting to be removed.
+type ConfigValueState int
+
+const (
+	ConfigValueApplied ConfigValueState = iota
+	ConfigValuePending
+	ConfigValuePendingDelete
+)
+
+func (s ConfigValueState) String() string {
+	switch s {
+	case ConfigValuePending:
+		return "pending"
+	case ConfigValuePendingDelete:
+		return "delete"
+	default:
+		return "applied"
+	}
+}
+
+// ConfigValue - current and pending value of a config key.
+// Pending is only meaningful when State is ConfigValuePending.
+type ConfigValue struct {
+	Key     string           `json:"key"`
+	Value   interface{}      `json:"value"`
+	Pending interface{}      `json:"pending"`
+	State   ConfigValueState `json:"state"`
+	// Removal forced (delete=2), the value is dropped even if it is still in use.
+	Force bool `json:"force"`
+}
+
+// GetConfigWithPending - Read a config section with its pending changes, e.g.
+// `/nodes/{node}/qemu/{vmid}/pending` or `/nodes/{node}/lxc/{vmid}/pending`.
+// Sections without pending support (a plain config object) are returned as applied values.
+func (c *Client) GetConfigWithPending(path string) (config map[string]ConfigValue, err error) {
+	data, err := getApiData[interface{}](c, path)
+	if err != nil {
+		return nil, err
+	}
+	config = map[string]ConfigValue{}
+	switch entries := data.(type) {
+	case []interface{}:
+		for _, entry := range entries {
+			entryMap, ok := entry.(map[string]interface{})
+			if !ok {
+				return nil, &ErrUnexpectedResponse{"pending config entry not readable", entry}
+			}
+			value := ConfigValue{
+				Key:     GetString(entryMap, "key"),
+				Value:   entryMap["value"],
+				Pending: entryMap["pending"],
+			}
+			if deleteFlag := GetIntDefault(entryMap, "delete", 0); deleteFlag > 0 {
+				value.State = ConfigValuePendingDelete
+				value.Force = deleteFlag == 2
+			} else if _, isPending := entryMap["pending"]; isPending {
+				value.State = ConfigValuePending
+			}
+			config[value.Key] = value
+		}
+	case map[string]interface{}:
+		for key, current := range entries {
+			config[key] = ConfigValue{Key: key, Value: current}
+		}
+	default:
+		return nil, &ErrUnexpectedResponse{"config not readable", data}
+	}
+	return
+}
+
+// GetVmPendingConfig - VM or container config with pending changes.
+func (c *Client) GetVmPendingConfig(vmr *VmRef) (config map[string]ConfigValue, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetConfigWithPending(ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "pending"))
+}
+
+// CloudInitStatus - cloud-init values of a VM not yet in its cloud-init drive. The drive must be
+// regenerated for the guest to see them, and a running guest only reads it at boot.
+type CloudInitStatus struct {
+	Values            map[string]ConfigValue
+	Pending           []string
+	NeedsRegeneration bool
+	NeedsRestart      bool
+}
+
+// checkCloudInitSupport - the cloudinit endpoint exists since PVE 7.3.
+func (c *Client) checkCloudInitSupport() error {
+	caps, err := c.GetCapabilities()
+	if err != nil {
+		return err
+	}
+	if !caps.CloudInitPending {
+		return fmt.Errorf("cloud-init pending values need PVE 7.3 or later")
+	}
+	return nil
+}
+
+// GetCloudInitStatus - Compare the cloud-init values of a VM config with those of its generated
+// cloud-init drive (PVE 7.3+).
+func (c *Client) GetCloudInitStatus(vmr *VmRef) (status *CloudInitStatus, err error) {
+	err = c.checkCloudInitSupport()
+	if err != nil {
+		return nil, err
+	}
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return nil, err
+	}
+	values, err := c.GetConfigWithPending(ApiPath("nodes", vmr.node, "qemu", vmr.vmId, "cloudinit"))
+	if err != nil {
+		return nil, err
+	}
+	status = &CloudInitStatus{Values: values}
+	for key, value := range values {
+		if value.State != ConfigValueApplied {
+			status.Pending = append(status.Pending, key)
+		}
+	}
+	sort.Strings(status.Pending)
+	status.NeedsRegeneration = len(status.Pending) > 0
+	if status.NeedsRegeneration {
+		vmState, err := c.GetVmState(vmr)
+		if err != nil {
+			return nil, err
+		}
+		status.NeedsRestart = GetString(vmState, "status") == "running"
+	}
+	return
+}
+
+// RegenerateCloudInit - Rebuild the cloud-init drive of a VM from its config (PVE 7.3+), a running
+// guest applies it at its next boot.
+func (c *Client) RegenerateCloudInit(vmr *VmRef) (err error) {
+	err = c.checkCloudInitSupport()
+	if err != nil {
+		return err
+	}
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return err
+	}
+	_, err = c.session.Put(ApiPath("nodes", vmr.node, "qemu", vmr.vmId, "cloudinit"), nil, nil, nil)
+	return
+}
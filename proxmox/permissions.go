@@ -0,0 +1,84 @@
+package proxmox
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// PrivilegeSet - privileges granted on an ACL path, a privilege maps to true when it propagates to sub paths.
+type PrivilegeSet map[string]bool
+
+// Has - are all privileges granted?
+func (set PrivilegeSet) Has(privileges ...string) bool {
+	return len(set.Missing(privileges...)) == 0
+}
+
+// Missing - privileges which are not granted, in the given order.
+func (set PrivilegeSet) Missing(privileges ...string) (missing []string) {
+	for _, privilege := range privileges {
+		if _, isSet := set[privilege]; !isSet {
+			missing = append(missing, privilege)
+		}
+	}
+	return
+}
+
+// Permissions - effective privileges of the authenticated user or token, by ACL path (`/vms/100`, `/storage/local`...).
+type Permissions map[string]PrivilegeSet
+
+// Paths - ACL paths with privileges, sorted.
+func (p Permissions) Paths() (paths []string) {
+	for path := range p {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return
+}
+
+// ErrMissingPrivileges - the user or token lacks privileges on an ACL path.
+type ErrMissingPrivileges struct {
+	Path    string
+	Missing []string
+}
+
+func (e *ErrMissingPrivileges) Error() string {
+	return fmt.Sprintf("missing privileges on %s: %s", e.Path, strings.Join(e.Missing, ", "))
+}
+
+// GetPermissions - Get the effective privileges of the authenticated user or token, on path only
+// when given (inherited privileges included) or on every path with an ACL when empty.
+func (c *Client) GetPermissions(path string) (permissions Permissions, err error) {
+	params := url.Values{}
+	if path != "" {
+		params.Set("path", path)
+	}
+	data, err := getApiDataWithParams[map[string]map[string]FlexBool](c, "/access/permissions", params)
+	if err != nil {
+		return nil, err
+	}
+	permissions = Permissions{}
+	for aclPath, privileges := range data {
+		set := PrivilegeSet{}
+		for privilege, propagate := range privileges {
+			set[privilege] = bool(propagate)
+		}
+		permissions[aclPath] = set
+	}
+	return
+}
+
+// CheckPrivileges - Check that the authenticated user or token has all privileges on path,
+// returns ErrMissingPrivileges otherwise. E.g. `CheckPrivileges("/vms/100", "VM.PowerMgmt")`.
+func (c *Client) CheckPrivileges(path string, privileges ...string) (err error) {
+	permissions, err := c.GetPermissions(path)
+	if err != nil {
+		return err
+	}
+	missing := permissions[path].Missing(privileges...)
+	if len(missing) > 0 {
+		return &ErrMissingPrivileges{Path: path, Missing: missing}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package proxmox
+
+import (
+	"fmt"
+)
+
+// PoolQuota - limits of a pool, zero means unlimited.
+type PoolQuota struct {
+	MaxVms      int
+	MaxCores    int
+	MaxMemoryMB int
+	MaxDiskGB   int
+}
+
+// QuotaRequest - resources an operation adds to a pool.
+type QuotaRequest struct {
+	Vms      int
+	Cores    int
+	MemoryMB int
+	DiskGB   float64
+}
+
+// ErrQuotaExceeded - the operation was refused client side because it would exceed the pool quota.
+type ErrQuotaExceeded struct {
+	Pool     string
+	Resource string
+	Limit    float64
+	Wanted   float64
+}
+
+func (e *ErrQuotaExceeded) Error() string {
+	return fmt.Sprintf("pool '%s' quota exceeded: %s would be %v, limit is %v", e.Pool, e.Resource, e.Wanted, e.Limit)
+}
+
+// CheckPoolQuota - check that request fits in the quota configured for pool, computed from cluster resources.
+// Pools without quota in Configuration.PoolQuotas are not limited.
+func (c *Client) CheckPoolQuota(pool string, request QuotaRequest) error {
+	quota, isLimited := c.configuration.PoolQuotas[pool]
+	if pool == "" || !isLimited {
+		return nil
+	}
+	report, err := c.GetResourceUsageByPool()
+	if err != nil {
+		return err
+	}
+	usage, exists := report[pool]
+	if !exists {
+		usage = &ResourceUsage{Group: pool}
+	}
+	const gb = 1 << 30
+	checks := []struct {
+		resource string
+		limit    int
+		wanted   float64
+	}{
+		{"vms", quota.MaxVms, float64(usage.VmCount + request.Vms)},
+		{"cores", quota.MaxCores, float64(usage.AllocatedCpu + request.Cores)},
+		{"memory (MB)", quota.MaxMemoryMB, float64(usage.AllocatedMemory>>20) + float64(request.MemoryMB)},
+		{"disk (GB)", quota.MaxDiskGB, float64(usage.AllocatedDisk)/gb + request.DiskGB},
+	}
+	for _, check := range checks {
+		if check.limit > 0 && check.wanted > float64(check.limit) {
+			return &ErrQuotaExceeded{Pool: pool, Resource: check.resource, Limit: float64(check.limit), Wanted: check.wanted}
+		}
+	}
+	return nil
+}
+
+// quotaRequest - resources the VM described by config would allocate.
+func (config ConfigQemu) quotaRequest() QuotaRequest {
+	request := QuotaRequest{
+		Vms:      1,
+		Cores:    config.QemuCores * config.QemuSockets,
+		MemoryMB: config.Memory,
+		DiskGB:   config.DiskSize,
+	}
+	if len(config.QemuDisks) > 0 {
+		request.DiskGB = 0
+	}
+	for _, diskConfMap := range config.QemuDisks {
+		sizeGB, _ := diskSizeGB(diskConfMap["size"])
+		request.DiskGB += sizeGB
+	}
+	return request
+}
@@ -0,0 +1,62 @@
+package proxmox
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResizeQemuDiskQuota(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		moreSizeGB int
+		wantResize bool
+	}{
+		{"within quota", 5, true},
+		{"over quota", 20, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			resized := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/cluster/resources":
+					w.Write([]byte(`{"data":[{"type":"qemu","vmid":100,"node":"pve","pool":"team","maxdisk":32212254720}]}`))
+				case "/nodes/pve/qemu/100/status/current":
+					// The node status of a VM has no pool.
+					w.Write([]byte(`{"data":{"vmid":100,"status":"running","maxdisk":32212254720}}`))
+				case "/nodes/pve/qemu/100/resize":
+					resized = true
+					w.Write([]byte(`{"data":null}`))
+				default:
+					t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			client, err := NewClient(&Configuration{
+				Url:        server.URL,
+				PoolQuotas: map[string]PoolQuota{"team": {MaxDiskGB: 40}},
+			}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Resolved VmRefs are looked up on their node, the pool still comes from the cluster.
+			vmr := NewVmRef(100)
+			vmr.SetNode("pve")
+			vmr.SetVmType("qemu")
+			_, err = client.ResizeQemuDisk(vmr, "scsi0", test.moreSizeGB)
+			var quotaErr *ErrQuotaExceeded
+			if test.wantResize && err != nil {
+				t.Errorf("err = %v, want nil", err)
+			}
+			if !test.wantResize && !errors.As(err, &quotaErr) {
+				t.Errorf("err = %v, want ErrQuotaExceeded", err)
+			}
+			if resized != test.wantResize {
+				t.Errorf("resized = %t, want %t", resized, test.wantResize)
+			}
+		})
+	}
+}
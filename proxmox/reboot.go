@@ -0,0 +1,119 @@
+package proxmox
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Config keys applied to running VMs without hotplug.
+var liveConfigKeys = []string{
+	"name", "description", "onboot", "tags", "protection", "startup", "hookscript",
+	"cpulimit", "cpuunits", "balloon", "shares", "lock", "digest",
+}
+
+var (
+	rxHotplugNic  = regexp.MustCompile(`^net\d+$`)
+	rxHotplugDisk = regexp.MustCompile(`^(ide|sata|scsi|virtio|unused)\d+$`)
+)
+
+// hotplugCategory - hotplug option category (network, disk, usb, memory, cpu) of a config key, empty when not hotpluggable.
+func hotplugCategory(key string) string {
+	switch {
+	case rxHotplugNic.MatchString(key):
+		return "network"
+	case rxHotplugDisk.MatchString(key):
+		return "disk"
+	case strings.HasPrefix(key, "usb"):
+		return "usb"
+	case key == "memory":
+		return "memory"
+	case key == "cores" || key == "sockets" || key == "vcpus":
+		return "cpu"
+	}
+	return ""
+}
+
+// hotplugCategories - categories enabled by the hotplug option, `network,disk,usb` by default.
+func hotplugCategories(hotplug string) []string {
+	switch hotplug {
+	case "":
+		return []string{"network", "disk", "usb"}
+	case "0":
+		return nil
+	case "1":
+		return []string{"network", "disk", "usb", "memory", "cpu"}
+	}
+	return strings.Split(hotplug, ",")
+}
+
+// PredictReboot - config keys of params that cannot be applied to the running VM with its current
+// hotplug settings and will stay pending until a reboot.
+func (c *Client) PredictReboot(vmr *VmRef, params map[string]interface{}) (keys []string, err error) {
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return nil, err
+	}
+	hotplug := hotplugCategories(GetString(vmConfig, "hotplug"))
+	for key := range params {
+		if key == "delete" || inArray(liveConfigKeys, key) {
+			continue
+		}
+		if category := hotplugCategory(key); category != "" && inArray(hotplug, category) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
+
+// NeedsReboot - Does the running VM have config changes that are only applied at the next
+// start? Changes stay pending when their hotplug category is disabled or hotplug failed.
+// Returns the pending keys, a stopped VM never needs a reboot.
+func (c *Client) NeedsReboot(vmr *VmRef) (needsReboot bool, keys []string, err error) {
+	vmState, err := c.GetVmState(vmr)
+	if err != nil {
+		return false, nil, err
+	}
+	if GetString(vmState, "status") != "running" {
+		return false, nil, nil
+	}
+	pending, err := c.GetVmPendingConfig(vmr)
+	if err != nil {
+		return false, nil, err
+	}
+	for key, value := range pending {
+		if value.State != ConfigValueApplied && !inArray(liveConfigKeys, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return len(keys) > 0, keys, nil
+}
+
+// ApplyAndRebootIfNeeded - Apply params to the VM config then, if changes are left pending,
+// reboot it gracefully and wait for its guest agent when enabled, at most agentTimeout.
+func (c *Client) ApplyAndRebootIfNeeded(vmr *VmRef, params map[string]interface{}, agentTimeout time.Duration) (rebooted bool, err error) {
+	_, err = c.SetVmConfig(vmr, params)
+	if err != nil {
+		return false, err
+	}
+	needsReboot, _, err := c.NeedsReboot(vmr)
+	if err != nil || !needsReboot {
+		return false, err
+	}
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return false, err
+	}
+	_, err = c.RebootVm(vmr)
+	if err != nil {
+		return false, err
+	}
+	if agent := ParseQemuAgent(GetString(vmConfig, "agent")); agent.Enabled {
+		err = c.WaitForAgent(vmr, agentTimeout)
+	}
+	return true, err
+}
@@ -0,0 +1,94 @@
+package proxmox
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RenameOptions - what RenameVm updates besides the name.
+type RenameOptions struct {
+	// Replace a tag equal to the old name by the new name.
+	Tags bool
+	// Replace whole-word occurrences of the old name in the description (notes).
+	Description bool
+	// Wait up to this long for /cluster/resources to report the new name, 0 does not wait.
+	Wait time.Duration
+}
+
+// Proxmox checks guest names against the DNS name format.
+var rxGuestName = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*$`)
+
+// RenameVm - Proxmox has no rename endpoint, set the name (hostname for containers) in the
+// guest config and, depending on options, the tags and description that carry the old name.
+func (c *Client) RenameVm(vmr *VmRef, newName string, options RenameOptions) (err error) {
+	if !rxGuestName.MatchString(newName) {
+		return fmt.Errorf("invalid guest name '%s'", newName)
+	}
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return err
+	}
+	nameKey := "name"
+	if vmr.vmType == "lxc" {
+		nameKey = "hostname"
+	}
+	oldName := GetString(vmConfig, nameKey)
+	params := map[string]interface{}{nameKey: newName}
+	if oldName != "" && oldName != newName {
+		if options.Tags {
+			if tags, changed := renameTag(GetString(vmConfig, "tags"), oldName, newName); changed {
+				params["tags"] = tags
+			}
+		}
+		if options.Description {
+			description := GetString(vmConfig, "description")
+			rxOldName := regexp.MustCompile(`(^|[^\w.-])` + regexp.QuoteMeta(oldName) + `($|[^\w-])`)
+			if renamed := rxOldName.ReplaceAllString(description, "${1}"+newName+"${2}"); renamed != description {
+				params["description"] = renamed
+			}
+		}
+	}
+	_, err = c.setGuestConfig(vmr, params)
+	if err != nil {
+		return err
+	}
+	if options.Wait > 0 {
+		return c.waitForVmName(vmr, newName, options.Wait)
+	}
+	return nil
+}
+
+// renameTag - tags with oldName replaced by newName, separators are normalized to `;`.
+func renameTag(tags string, oldName string, newName string) (string, bool) {
+	list := splitTags(tags)
+	changed := false
+	for i, tag := range list {
+		if tag == oldName {
+			list[i] = newName
+			changed = true
+		}
+	}
+	return strings.Join(list, ";"), changed
+}
+
+// waitForVmName - wait until the cluster resources, updated asynchronously by pvestatd, report name for vmr.
+func (c *Client) waitForVmName(vmr *VmRef, name string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		vms, err := c.GetVmResources()
+		if err != nil {
+			return err
+		}
+		for _, vm := range vms {
+			if int(vm.VmId) == vmr.vmId && vm.Name == name {
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("VM %d not reported as '%s' by the cluster after %s", vmr.vmId, name, timeout)
+		}
+		time.Sleep(TaskStatusCheckInterval * time.Second)
+	}
+}
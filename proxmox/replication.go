@@ -0,0 +1,97 @@
+package proxmox
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ReplicationJob - storage replication job of a guest.
+type ReplicationJob struct {
+	Id       string `json:"id"`
+	Guest    int    `json:"guest"`
+	Target   string `json:"target"`
+	Schedule string `json:"schedule"`
+	Disabled bool   `json:"disable"`
+}
+
+// ErrReplicationJobs - the guest cannot be deleted while these replication jobs exist.
+type ErrReplicationJobs struct {
+	VmId int
+	Jobs []ReplicationJob
+}
+
+func (e *ErrReplicationJobs) Error() string {
+	ids := []string{}
+	for _, job := range e.Jobs {
+		ids = append(ids, job.Id+" (to "+job.Target+")")
+	}
+	return fmt.Sprintf("Vm '%d' has replication jobs: %s", e.VmId, strings.Join(ids, ", "))
+}
+
+// GetReplicationJobs - replication jobs of the guest.
+func (c *Client) GetReplicationJobs(vmr *VmRef) (jobs []ReplicationJob, err error) {
+	entries, err := getApiData[[]map[string]interface{}](c, "/cluster/replication")
+	if err != nil {
+		return nil, err
+	}
+	for _, jobMap := range entries {
+		if GetIntDefault(jobMap, "guest", 0) != vmr.vmId {
+			continue
+		}
+		jobs = append(jobs, ReplicationJob{
+			Id:       GetString(jobMap, "id"),
+			Guest:    vmr.vmId,
+			Target:   GetString(jobMap, "target"),
+			Schedule: GetString(jobMap, "schedule"),
+			Disabled: Itob(GetIntDefault(jobMap, "disable", 0)),
+		})
+	}
+	return
+}
+
+// DeleteReplicationJob - mark a replication job for removal, its replicated volumes are cleaned up
+// on the target by the replication runner. With force only the job config is removed.
+func (c *Client) DeleteReplicationJob(id string, force bool) (err error) {
+	params := url.Values{}
+	if force {
+		params.Set("force", "1")
+	}
+	_, err = c.session.Delete(ApiPath("cluster", "replication", id), &params, nil)
+	return
+}
+
+// DeleteVmWithReplication - Delete a guest taking care of its replication jobs, which otherwise block the deletion.
+// Without removeJobs an ErrReplicationJobs listing the blocking jobs is returned and nothing is deleted.
+func (c *Client) DeleteVmWithReplication(vmr *VmRef, removeJobs bool) (exitStatus string, err error) {
+	jobs, err := c.GetReplicationJobs(vmr)
+	if err != nil {
+		return "", err
+	}
+	if len(jobs) > 0 {
+		if !removeJobs {
+			return "", &ErrReplicationJobs{VmId: vmr.vmId, Jobs: jobs}
+		}
+		for _, job := range jobs {
+			err = c.DeleteReplicationJob(job.Id, false)
+			if err != nil {
+				return "", err
+			}
+		}
+		// Jobs disappear once the runner has cleaned them up.
+		timeout, _, _ := c.taskPolling()
+		start := time.Now()
+		for len(jobs) > 0 {
+			if time.Since(start) > timeout {
+				return "", &ErrReplicationJobs{VmId: vmr.vmId, Jobs: jobs}
+			}
+			time.Sleep(TaskStatusCheckInterval * time.Second)
+			jobs, err = c.GetReplicationJobs(vmr)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+	return c.DeleteVm(vmr)
+}
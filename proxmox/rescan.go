@@ -0,0 +1,84 @@
+package proxmox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// guestVolumeRefs - volids referenced by a guest config, whatever the option (disks, efidisk,
+// tpmstate, unusedN, mount points, rootfs).
+func guestVolumeRefs(vmConfig map[string]interface{}, refs map[string]bool) {
+	for _, value := range vmConfig {
+		conf, ok := value.(string)
+		if !ok {
+			continue
+		}
+		volid := strings.SplitN(conf, ",", 2)[0]
+		if strings.Contains(volid, ":") {
+			refs[volid] = true
+		}
+	}
+}
+
+// RescanVmDisks - Equivalent of `qm rescan` for one guest: volumes owned by vmid on the storages of its
+// node that neither its config nor its snapshots reference are attached as unusedN entries, so disks
+// created outside of Proxmox become visible. Returns the volids attached.
+func (c *Client) RescanVmDisks(vmr *VmRef) (attached []string, err error) {
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return nil, err
+	}
+	refs := map[string]bool{}
+	guestVolumeRefs(vmConfig, refs)
+	snapshots, err := c.ListSnapshots(vmr)
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshot := range snapshots {
+		var snapshotConfig map[string]interface{}
+		snapshotConfig, err = getApiData[map[string]interface{}](c, ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "snapshot", snapshot.Name, "config"))
+		if err != nil {
+			return nil, err
+		}
+		guestVolumeRefs(snapshotConfig, refs)
+	}
+
+	contentType := "images"
+	if vmr.vmType == "lxc" {
+		contentType = "rootdir"
+	}
+	storages, err := c.FindStorages(contentType, vmr.node, false)
+	if err != nil {
+		return nil, err
+	}
+	unusedID := 0
+	params := map[string]interface{}{}
+	for _, storage := range storages {
+		var content []map[string]interface{}
+		content, err = c.GetStorageContent(vmr.node, storage.Storage, vmr.vmId)
+		if err != nil {
+			return nil, err
+		}
+		for _, volume := range content {
+			volid := GetString(volume, "volid")
+			// Only disk images, not backups or templates stored along.
+			if refs[volid] || (GetString(volume, "content") != contentType && GetString(volume, "content") != "images") {
+				continue
+			}
+			for vmConfig[fmt.Sprintf("unused%d", unusedID)] != nil || params[fmt.Sprintf("unused%d", unusedID)] != nil {
+				unusedID++
+			}
+			params[fmt.Sprintf("unused%d", unusedID)] = volid
+			refs[volid] = true
+			attached = append(attached, volid)
+		}
+	}
+	if len(params) == 0 {
+		return nil, nil
+	}
+	_, err = c.setGuestConfig(vmr, params)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
@@ -0,0 +1,102 @@
+package proxmox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Keys used to group VMs when aggregating resource usage.
+const (
+	ResourceGroupPool = "pool"
+	ResourceGroupTag  = "tag"
+	ResourceGroupNode = "node"
+)
+
+// ResourceUsage - allocated and used resources summed over a group of VMs.
+// Memory and disk are in bytes, UsedCpu is the sum of per-VM usage expressed in cores.
+type ResourceUsage struct {
+	Group           string  `json:"group"`
+	VmCount         int     `json:"vmcount"`
+	AllocatedCpu    int     `json:"maxcpu"`
+	UsedCpu         float64 `json:"cpu"`
+	AllocatedMemory int64   `json:"maxmem"`
+	UsedMemory      int64   `json:"mem"`
+	AllocatedDisk   int64   `json:"maxdisk"`
+	UsedDisk        int64   `json:"disk"`
+}
+
+// ResourceUsageReport - resource usage keyed by group name (pool, tag or node).
+type ResourceUsageReport map[string]*ResourceUsage
+
+func (usage *ResourceUsage) add(vm map[string]interface{}) {
+	maxcpu := GetFloatDefault(vm, "maxcpu", 0)
+	cpu := GetFloatDefault(vm, "cpu", 0)
+	maxmem := GetFloatDefault(vm, "maxmem", 0)
+	mem := GetFloatDefault(vm, "mem", 0)
+	maxdisk := GetFloatDefault(vm, "maxdisk", 0)
+	disk := GetFloatDefault(vm, "disk", 0)
+
+	usage.VmCount++
+	usage.AllocatedCpu += int(maxcpu)
+	usage.UsedCpu += cpu * maxcpu
+	usage.AllocatedMemory += int64(maxmem)
+	usage.UsedMemory += int64(mem)
+	usage.AllocatedDisk += int64(maxdisk)
+	usage.UsedDisk += int64(disk)
+}
+
+// vmGroups - names of the groups a VM belongs to for the given grouping key.
+// A VM has exactly one pool and node (possibly empty) but may carry any number of tags.
+func vmGroups(vm map[string]interface{}, groupBy string) []string {
+	switch groupBy {
+	case ResourceGroupTag:
+		tags, _ := vm["tags"].(string)
+		return splitTags(tags)
+	default:
+		group, _ := vm[groupBy].(string)
+		return []string{group}
+	}
+}
+
+// splitTags - Proxmox accepts `;`, `,` and spaces as tag separators.
+func splitTags(tags string) []string {
+	return strings.FieldsFunc(tags, func(r rune) bool {
+		return r == ';' || r == ',' || r == ' '
+	})
+}
+
+// GetResourceUsage - sum allocated/used CPU, memory and disk of all VMs grouped by pool, tag or node.
+// VMs without pool are reported under the empty group name, VMs without tags are not reported when grouping by tag.
+func (c *Client) GetResourceUsage(groupBy string) (report ResourceUsageReport, err error) {
+	if groupBy != ResourceGroupPool && groupBy != ResourceGroupTag && groupBy != ResourceGroupNode {
+		return nil, fmt.Errorf("unsupported resource grouping '%s'", groupBy)
+	}
+	vms, err := getApiData[[]map[string]interface{}](c, "/cluster/resources?type=vm")
+	if err != nil {
+		return nil, err
+	}
+	report = ResourceUsageReport{}
+	for _, vm := range vms {
+		for _, group := range vmGroups(vm, groupBy) {
+			usage, exists := report[group]
+			if !exists {
+				usage = &ResourceUsage{Group: group}
+				report[group] = usage
+			}
+			usage.add(vm)
+		}
+	}
+	return
+}
+
+func (c *Client) GetResourceUsageByPool() (ResourceUsageReport, error) {
+	return c.GetResourceUsage(ResourceGroupPool)
+}
+
+func (c *Client) GetResourceUsageByTag() (ResourceUsageReport, error) {
+	return c.GetResourceUsage(ResourceGroupTag)
+}
+
+func (c *Client) GetResourceUsageByNode() (ResourceUsageReport, error) {
+	return c.GetResourceUsage(ResourceGroupNode)
+}
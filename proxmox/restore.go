@@ -0,0 +1,161 @@
+package proxmox
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// RestoreVolume - volume of a backup archive and where it would be restored.
+type RestoreVolume struct {
+	Key           string
+	SourceStorage string
+	TargetStorage string
+	SizeBytes     int64
+}
+
+// RestoreStorage - space needed on a target storage against its free space.
+type RestoreStorage struct {
+	Storage     string
+	NeededBytes int64
+	AvailBytes  int64
+}
+
+// RestoreReport - outcome of RestorePreflight. Problems lists, in plain words, every reason
+// the restore would fail: the vmid in use, storages missing or not accepting guest volumes
+// on the node and storages without enough free space.
+type RestoreReport struct {
+	Volid           string
+	VmId            int
+	VmType          string
+	VmIdInUse       bool
+	Volumes         []RestoreVolume
+	MissingStorages []string
+	Storages        []RestoreStorage
+	Problems        []string
+}
+
+// Ok - whether the restore is expected to succeed.
+func (report RestoreReport) Ok() bool {
+	return len(report.Problems) == 0
+}
+
+// backupGuestType - guest type of a backup volid, from the vzdump archive name
+// (`vzdump-qemu-100-...`) or the PBS snapshot (`backup/vm/100/...`).
+func backupGuestType(volid string) (vmType string, err error) {
+	_, volumeName := getStorageAndVolumeName(volid, ":")
+	switch {
+	case strings.Contains(volumeName, "vzdump-qemu-") || strings.HasPrefix(volumeName, "backup/vm/"):
+		return "qemu", nil
+	case strings.Contains(volumeName, "vzdump-lxc-") || strings.HasPrefix(volumeName, "backup/ct/"):
+		return "lxc", nil
+	}
+	return "", fmt.Errorf("'%s' is not a guest backup", volid)
+}
+
+// GetBackupConfig - Guest config stored in the backup archive volid, read on node.
+func (c *Client) GetBackupConfig(node string, volid string) (config map[string]interface{}, err error) {
+	conf, err := getApiDataWithParams[string](c, ApiPath("nodes", node, "vzdump", "extractconfig"), url.Values{"volume": {volid}})
+	if err != nil {
+		return nil, err
+	}
+	config = map[string]interface{}{}
+	for _, line := range strings.Split(conf, "\n") {
+		// Snapshot sections follow the current config.
+		if strings.HasPrefix(line, "[") {
+			break
+		}
+		key, value, found := strings.Cut(line, ": ")
+		if !found || strings.HasPrefix(key, "#") {
+			continue
+		}
+		config[key] = value
+	}
+	return
+}
+
+// RestorePreflight - Check, without restoring, that the backup volid can be restored on node as
+// vmid. storage is the restore target storage, empty to restore each volume to the storage it
+// was backed up from. Returns an error only when the checks themselves fail.
+func (c *Client) RestorePreflight(node string, volid string, vmid int, storage string) (report *RestoreReport, err error) {
+	vmType, err := backupGuestType(volid)
+	if err != nil {
+		return nil, err
+	}
+	backupConfig, err := c.GetBackupConfig(node, volid)
+	if err != nil {
+		return nil, err
+	}
+	report = &RestoreReport{Volid: volid, VmId: vmid, VmType: vmType}
+
+	index, err := c.GetVmIndex()
+	if err != nil {
+		return nil, err
+	}
+	if vmInfo, exists := index[vmid]; exists {
+		report.VmIdInUse = true
+		report.Problems = append(report.Problems, fmt.Sprintf("vmid %d is already used on node '%s'", vmid, GetString(vmInfo, "node")))
+	}
+
+	keys := make([]string, 0, len(backupConfig))
+	for key := range backupConfig {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	needed := map[string]int64{}
+	var targets []string
+	for _, key := range keys {
+		conf := GetString(backupConfig, key)
+		volume := strings.SplitN(conf, ",", 2)[0]
+		if !rxGuestVolume.MatchString(key) || !strings.Contains(volume, ":") {
+			// Not a volume, or a bind mount point.
+			continue
+		}
+		confMap := ParseConf(conf, ",", "=")
+		if GetString(confMap, "media") == "cdrom" || GetString(confMap, "backup") == "0" {
+			continue
+		}
+		sizeGB, _ := diskSizeGB(confMap["size"])
+		restoreVolume := RestoreVolume{
+			Key:           key,
+			SourceStorage: strings.SplitN(volume, ":", 2)[0],
+			TargetStorage: storage,
+			SizeBytes:     int64(sizeGB * 1024 * 1024 * 1024),
+		}
+		if restoreVolume.TargetStorage == "" {
+			restoreVolume.TargetStorage = restoreVolume.SourceStorage
+		}
+		report.Volumes = append(report.Volumes, restoreVolume)
+		if _, seen := needed[restoreVolume.TargetStorage]; !seen {
+			targets = append(targets, restoreVolume.TargetStorage)
+		}
+		needed[restoreVolume.TargetStorage] += restoreVolume.SizeBytes
+	}
+
+	contentType := "images"
+	if vmType == "lxc" {
+		contentType = "rootdir"
+	}
+	candidates, err := c.FindStorages(contentType, node, false)
+	if err != nil {
+		return nil, err
+	}
+	avail := map[string]int64{}
+	for _, candidate := range candidates {
+		avail[candidate.Storage] = candidate.Avail
+	}
+	for _, target := range targets {
+		free, exists := avail[target]
+		if !exists {
+			report.MissingStorages = append(report.MissingStorages, target)
+			report.Problems = append(report.Problems, fmt.Sprintf("storage '%s' is not available for %s on node '%s'", target, contentType, node))
+			continue
+		}
+		report.Storages = append(report.Storages, RestoreStorage{Storage: target, NeededBytes: needed[target], AvailBytes: free})
+		if needed[target] > free {
+			report.Problems = append(report.Problems, fmt.Sprintf("storage '%s' needs %d bytes but has %d free", target, needed[target], free))
+		}
+	}
+	return
+}
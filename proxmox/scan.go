@@ -0,0 +1,81 @@
+package proxmox
+
+import (
+	"net/url"
+)
+
+// NfsExport - export of an NFS server.
+type NfsExport struct {
+	Path    string `json:"path"`
+	Options string `json:"options"`
+}
+
+// CifsShare - share of a CIFS/SMB server.
+type CifsShare struct {
+	Share       string `json:"share"`
+	Description string `json:"description"`
+}
+
+// IscsiTarget - target found on an iSCSI portal.
+type IscsiTarget struct {
+	Target string `json:"target"`
+	Portal string `json:"portal"`
+}
+
+// ScanStorage - Query node for storages of scanType (nfs, cifs, glusterfs, iscsi, zfs, lvm, lvmthin, pbs)
+// reachable with params, before creating the storage definition.
+func (c *Client) ScanStorage(node string, scanType string, params map[string]string) (results []map[string]interface{}, err error) {
+	query := url.Values{}
+	for k, v := range params {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+	return getApiDataWithParams[[]map[string]interface{}](c, ApiPath("nodes", node, "scan", scanType), query)
+}
+
+// ScanNfs - exports of an NFS server as seen from node.
+func (c *Client) ScanNfs(node string, server string) (exports []NfsExport, err error) {
+	results, err := c.ScanStorage(node, "nfs", map[string]string{"server": server})
+	for _, result := range results {
+		exports = append(exports, NfsExport{Path: GetString(result, "path"), Options: GetString(result, "options")})
+	}
+	return
+}
+
+// ScanCifs - shares of a CIFS server as seen from node, credentials are optional.
+func (c *Client) ScanCifs(node string, server string, username string, password string, domain string) (shares []CifsShare, err error) {
+	params := map[string]string{"server": server, "username": username, "password": password, "domain": domain}
+	results, err := c.ScanStorage(node, "cifs", params)
+	for _, result := range results {
+		shares = append(shares, CifsShare{Share: GetString(result, "share"), Description: GetString(result, "description")})
+	}
+	return
+}
+
+// ScanGlusterfs - volumes of a GlusterFS server as seen from node.
+func (c *Client) ScanGlusterfs(node string, server string) (volumes []string, err error) {
+	results, err := c.ScanStorage(node, "glusterfs", map[string]string{"server": server})
+	for _, result := range results {
+		volumes = append(volumes, GetString(result, "volname"))
+	}
+	return
+}
+
+// ScanIscsi - targets of an iSCSI portal as seen from node.
+func (c *Client) ScanIscsi(node string, portal string) (targets []IscsiTarget, err error) {
+	results, err := c.ScanStorage(node, "iscsi", map[string]string{"portal": portal})
+	for _, result := range results {
+		targets = append(targets, IscsiTarget{Target: GetString(result, "target"), Portal: GetString(result, "portal")})
+	}
+	return
+}
+
+// ScanZfs - local ZFS pools of node.
+func (c *Client) ScanZfs(node string) (pools []string, err error) {
+	results, err := c.ScanStorage(node, "zfs", nil)
+	for _, result := range results {
+		pools = append(pools, GetString(result, "pool"))
+	}
+	return
+}
@@ -0,0 +1,273 @@
+package proxmox
+
+import (
+	"bytes"
+	"crypto/des"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/url"
+)
+
+// Screenshot - capture the VM console as a PNG image.
+// A VNC proxy is opened on the node and a single full framebuffer update is read over its websocket.
+func (c *Client) Screenshot(vmr *VmRef) (screenshot []byte, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return nil, err
+	}
+	reqbody := ParamsToBody(map[string]interface{}{"websocket": true})
+	proxyUrl := ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "vncproxy")
+	resp, err := c.session.Post(proxyUrl, nil, nil, &reqbody)
+	if err != nil {
+		return nil, err
+	}
+	proxyResponse := ResponseJSON(resp)
+	proxy, ok := proxyResponse["data"].(map[string]interface{})
+	if !ok {
+		return nil, &ErrUnexpectedResponse{"vncproxy response not readable", proxyResponse}
+	}
+	port := GetString(proxy, "port")
+	ticket := GetString(proxy, "ticket")
+
+	wsPath := ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "vncwebsocket") +
+		"?" + url.Values{"port": {port}, "vncticket": {ticket}}.Encode()
+	ws, err := c.session.DialWebsocket(wsPath, "binary")
+	if err != nil {
+		return nil, err
+	}
+	defer ws.Close()
+
+	img, err := rfbCaptureFramebuffer(ws, ticket)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = png.Encode(&buf, img)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RFB (VNC) protocol, just enough to authenticate and read one raw framebuffer.
+const (
+	rfbVersion          = "RFB 003.008\n"
+	rfbSecurityNone     = 1
+	rfbSecurityVncAuth  = 2
+	rfbFramebufferMsg   = 0
+	rfbColourMapMsg     = 1
+	rfbBellMsg          = 2
+	rfbServerCutTextMsg = 3
+	rfbEncodingRaw      = 0
+)
+
+func rfbCaptureFramebuffer(conn io.ReadWriter, password string) (img *image.RGBA, err error) {
+	version := make([]byte, 12)
+	_, err = io.ReadFull(conn, version)
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.Write([]byte(rfbVersion))
+	if err != nil {
+		return nil, err
+	}
+
+	err = rfbAuthenticate(conn, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// ClientInit with shared flag, then ServerInit.
+	_, err = conn.Write([]byte{1})
+	if err != nil {
+		return nil, err
+	}
+	var serverInit struct {
+		Width, Height uint16
+		PixelFormat   [16]byte
+		NameLength    uint32
+	}
+	err = binary.Read(conn, binary.BigEndian, &serverInit)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.CopyN(io.Discard, conn, int64(serverInit.NameLength))
+	if err != nil {
+		return nil, err
+	}
+	width, height := int(serverInit.Width), int(serverInit.Height)
+
+	// 32 bits true colour little endian pixels, raw encoding only.
+	setPixelFormat := []byte{0, 0, 0, 0, 32, 24, 0, 1, 0, 255, 0, 255, 0, 255, 16, 8, 0, 0, 0, 0}
+	setEncodings := []byte{2, 0, 0, 1, 0, 0, 0, rfbEncodingRaw}
+	updateRequest := []byte{3, 0, 0, 0, 0, 0, byte(width >> 8), byte(width), byte(height >> 8), byte(height)}
+	for _, msg := range [][]byte{setPixelFormat, setEncodings, updateRequest} {
+		_, err = conn.Write(msg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	img = image.NewRGBA(image.Rect(0, 0, width, height))
+	for {
+		msgType := make([]byte, 1)
+		_, err = io.ReadFull(conn, msgType)
+		if err != nil {
+			return nil, err
+		}
+		switch msgType[0] {
+		case rfbFramebufferMsg:
+			return img, rfbReadRawRects(conn, img)
+		case rfbColourMapMsg:
+			var header struct {
+				Padding    byte
+				FirstColor uint16
+				Colors     uint16
+			}
+			err = binary.Read(conn, binary.BigEndian, &header)
+			if err == nil {
+				_, err = io.CopyN(io.Discard, conn, int64(header.Colors)*6)
+			}
+		case rfbBellMsg:
+		case rfbServerCutTextMsg:
+			var header struct {
+				Padding [3]byte
+				Length  uint32
+			}
+			err = binary.Read(conn, binary.BigEndian, &header)
+			if err == nil {
+				_, err = io.CopyN(io.Discard, conn, int64(header.Length))
+			}
+		default:
+			return nil, fmt.Errorf("unsupported VNC server message %d", msgType[0])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func rfbAuthenticate(conn io.ReadWriter, password string) (err error) {
+	count := make([]byte, 1)
+	_, err = io.ReadFull(conn, count)
+	if err != nil {
+		return err
+	}
+	if count[0] == 0 {
+		return errors.New("VNC server refused the connection")
+	}
+	securityTypes := make([]byte, count[0])
+	_, err = io.ReadFull(conn, securityTypes)
+	if err != nil {
+		return err
+	}
+	securityType := byte(0)
+	for _, t := range securityTypes {
+		if t == rfbSecurityVncAuth || (t == rfbSecurityNone && securityType == 0) {
+			securityType = t
+		}
+	}
+	if securityType == 0 {
+		return fmt.Errorf("no supported VNC security type in %v", securityTypes)
+	}
+	_, err = conn.Write([]byte{securityType})
+	if err != nil {
+		return err
+	}
+
+	if securityType == rfbSecurityVncAuth {
+		challenge := make([]byte, 16)
+		_, err = io.ReadFull(conn, challenge)
+		if err != nil {
+			return err
+		}
+		response, err := vncAuthResponse(challenge, password)
+		if err != nil {
+			return err
+		}
+		_, err = conn.Write(response)
+		if err != nil {
+			return err
+		}
+	}
+
+	var result uint32
+	err = binary.Read(conn, binary.BigEndian, &result)
+	if err != nil {
+		return err
+	}
+	if result != 0 {
+		return errors.New("VNC authentication failed")
+	}
+	return nil
+}
+
+// vncAuthResponse - DES encryption of the challenge, keyed with the password bytes bit-reversed.
+func vncAuthResponse(challenge []byte, password string) ([]byte, error) {
+	key := make([]byte, 8)
+	copy(key, password)
+	for i, b := range key {
+		var reversed byte
+		for bit := 0; bit < 8; bit++ {
+			reversed |= ((b >> uint(bit)) & 1) << uint(7-bit)
+		}
+		key[i] = reversed
+	}
+	cipher, err := des.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	response := make([]byte, 16)
+	cipher.Encrypt(response[:8], challenge[:8])
+	cipher.Encrypt(response[8:], challenge[8:])
+	return response, nil
+}
+
+func rfbReadRawRects(conn io.Reader, img *image.RGBA) (err error) {
+	var header struct {
+		Padding byte
+		Rects   uint16
+	}
+	err = binary.Read(conn, binary.BigEndian, &header)
+	if err != nil {
+		return err
+	}
+	for r := 0; r < int(header.Rects); r++ {
+		var rect struct {
+			X, Y, Width, Height uint16
+			Encoding            int32
+		}
+		err = binary.Read(conn, binary.BigEndian, &rect)
+		if err != nil {
+			return err
+		}
+		if rect.Encoding != rfbEncodingRaw {
+			return fmt.Errorf("unsupported VNC encoding %d", rect.Encoding)
+		}
+		pixels := make([]byte, int(rect.Width)*int(rect.Height)*4)
+		_, err = io.ReadFull(conn, pixels)
+		if err != nil {
+			return err
+		}
+		for y := 0; y < int(rect.Height); y++ {
+			for x := 0; x < int(rect.Width); x++ {
+				point := image.Pt(int(rect.X)+x, int(rect.Y)+y)
+				if !point.In(img.Rect) {
+					continue
+				}
+				pixel := pixels[(y*int(rect.Width)+x)*4:]
+				offset := img.PixOffset(point.X, point.Y)
+				// Little endian 0x00RRGGBB pixels are stored B, G, R, X.
+				img.Pix[offset] = pixel[2]
+				img.Pix[offset+1] = pixel[1]
+				img.Pix[offset+2] = pixel[0]
+				img.Pix[offset+3] = 255
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package proxmox
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// SdnIpam - IPAM plugin of the SDN (pve, netbox, phpipam).
+type SdnIpam struct {
+	Ipam    string `json:"ipam"`
+	Type    string `json:"type"`
+	Url     string `json:"url"`
+	Section string `json:"section"`
+}
+
+// SdnIpamEntry - IP address allocated in an SDN subnet.
+type SdnIpamEntry struct {
+	Zone     string   `json:"zone"`
+	Vnet     string   `json:"vnet"`
+	Subnet   string   `json:"subnet"`
+	Ip       string   `json:"ip"`
+	Mac      string   `json:"mac"`
+	Hostname string   `json:"hostname"`
+	VmId     FlexInt  `json:"vmid"`
+	Gateway  FlexBool `json:"gateway"`
+}
+
+// GetSdnIpams - List the SDN IPAM plugins.
+func (c *Client) GetSdnIpams() (ipams []SdnIpam, err error) {
+	return getApiData[[]SdnIpam](c, "/cluster/sdn/ipams")
+}
+
+// GetSdnIpamEntries - List the IP addresses allocated by an IPAM (PVE 8.1+).
+func (c *Client) GetSdnIpamEntries(ipam string) (entries []SdnIpamEntry, err error) {
+	return getApiData[[]SdnIpamEntry](c, ApiPath("cluster", "sdn", "ipams", ipam, "status"))
+}
+
+// ReserveSdnIp - Reserve ip in a subnet of vnet for mac, before the guest is created (PVE 8.1+).
+func (c *Client) ReserveSdnIp(zone string, vnet string, ip string, mac string) (err error) {
+	params := map[string]interface{}{
+		"zone": zone,
+		"ip":   ip,
+	}
+	if mac != "" {
+		params["mac"] = mac
+	}
+	reqbody := ParamsToBody(params)
+	_, err = c.session.Post(ApiPath("cluster", "sdn", "vnets", vnet, "ips"), nil, nil, &reqbody)
+	return
+}
+
+// UpdateSdnIp - Change the MAC address or guest of a reserved ip of vnet (PVE 8.1+), zero vmid is left unchanged.
+func (c *Client) UpdateSdnIp(zone string, vnet string, ip string, mac string, vmid int) (err error) {
+	params := map[string]interface{}{
+		"zone": zone,
+		"ip":   ip,
+	}
+	if mac != "" {
+		params["mac"] = mac
+	}
+	if vmid > 0 {
+		params["vmid"] = vmid
+	}
+	reqbody := ParamsToBody(params)
+	_, err = c.session.Put(ApiPath("cluster", "sdn", "vnets", vnet, "ips"), nil, nil, &reqbody)
+	return
+}
+
+// ReleaseSdnIp - Release a reserved ip of vnet (PVE 8.1+).
+func (c *Client) ReleaseSdnIp(zone string, vnet string, ip string, mac string) (err error) {
+	params := url.Values{}
+	params.Set("zone", zone)
+	params.Set("ip", ip)
+	if mac != "" {
+		params.Set("mac", mac)
+	}
+	_, err = c.session.Delete(ApiPath("cluster", "sdn", "vnets", vnet, "ips"), &params, nil)
+	return
+}
+
+// SdnZone - SDN zone with its pending changes, State is `new`, `changed` or `deleted` when the
+// zone has changes not applied yet and empty otherwise.
+type SdnZone struct {
+	Zone    string                 `json:"zone"`
+	Type    string                 `json:"type"`
+	State   string                 `json:"state"`
+	Pending map[string]interface{} `json:"pending"`
+}
+
+// SdnZoneStatus - state of a zone on a node: `available`, `pending` (not reloaded yet) or `error`.
+type SdnZoneStatus struct {
+	Zone   string `json:"sdn"`
+	Node   string `json:"node"`
+	Status string `json:"status"`
+}
+
+// GetSdnZones - List the SDN zones with their pending changes.
+func (c *Client) GetSdnZones() (zones []SdnZone, err error) {
+	return getApiData[[]SdnZone](c, "/cluster/sdn/zones?pending=1")
+}
+
+// GetSdnPendingZones - names of the zones with changes not applied yet.
+func (c *Client) GetSdnPendingZones() (zones []string, err error) {
+	list, err := c.GetSdnZones()
+	if err != nil {
+		return nil, err
+	}
+	for _, zone := range list {
+		if zone.State != "" {
+			zones = append(zones, zone.Zone)
+		}
+	}
+	sort.Strings(zones)
+	return
+}
+
+// GetSdnZoneStatus - state of every zone on every node.
+func (c *Client) GetSdnZoneStatus() (status []SdnZoneStatus, err error) {
+	return getApiData[[]SdnZoneStatus](c, "/cluster/resources?type=sdn")
+}
+
+// ApplySdn - Apply the pending SDN changes and reload the network of every node, waiting for the
+// reload task. Nodes that failed to reload report their zones in `error` in GetSdnZoneStatus.
+func (c *Client) ApplySdn() (exitStatus string, err error) {
+	resp, err := c.session.Put("/cluster/sdn", nil, nil, nil)
+	if err == nil {
+		taskResponse := ResponseJSON(resp)
+		exitStatus, err = c.WaitForCompletion(taskResponse)
+	}
+	return
+}
+
+// RollbackSdn - Discard the SDN changes not applied yet, restoring the running configuration (PVE 8.2+).
+func (c *Client) RollbackSdn() (err error) {
+	version, err := c.GetVersion()
+	if err != nil {
+		return err
+	}
+	if !version.AtLeast(8, 2) {
+		return fmt.Errorf("SDN rollback needs PVE 8.2 or later, cluster runs %s", version)
+	}
+	_, err = c.session.Post("/cluster/sdn/rollback", nil, nil, nil)
+	return
+}
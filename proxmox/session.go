@@ -4,6 +4,8 @@ package proxmox
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
@@ -11,6 +13,7 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"net"
 	"time"
 	"net/http"
 	"net/http/httputil"
@@ -22,25 +25,54 @@ var Debug = new(bool)
 type ApiError struct {
     Code    int
     Message string
+    // Per-parameter validation errors returned with the response.
+    Errors  map[string]string
 }
 
 func (e *ApiError) Error() string {
+    if len(e.Errors) > 0 {
+        return fmt.Sprintf("Proxmox API error %d: %s (%s)", e.Code, e.Message, formatApiErrors(e.Errors))
+    }
     return fmt.Sprintf("Proxmox API error %d: %s", e.Code, e.Message)
 }
 
 const ApiErrorTooManyRedirections = 599
 
+// isSafeToRetry - can a non-idempotent request failing with err be sent again? True when the
+// connection could not be established, so the request never left, or when the server refused
+// it before processing (429 Too Many Requests, 503 Service Unavailable).
+func isSafeToRetry(err error) bool {
+	var apiErr *ApiError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusTooManyRequests || apiErr.Code == http.StatusServiceUnavailable
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
 type Response struct {
 	Resp *http.Response
 	Body []byte
 }
 
 type Session struct {
-	httpClient *http.Client
-	ApiUrl     string
-	AuthTicket string
-	CsrfToken  string
-	Headers    http.Header
+	httpClient      *http.Client
+	ApiUrl          string
+	AuthTicket      string
+	CsrfToken       string
+	// API token authorization, `user@realm!name=secret`.
+	ApiToken        string
+	Headers         http.Header
+	// Timeout of each request and of uploads/downloads, zero means no limit.
+	Timeout         time.Duration
+	TransferTimeout time.Duration
+	breaker         *circuitBreaker
+	totpCode        func() (string, error)
+	transient       transientRetry
 }
 
 func NewSession(configuration *Configuration, httpClient *http.Client) (session *Session, err error) {
@@ -52,18 +84,54 @@ func NewSession(configuration *Configuration, httpClient *http.Client) (session
 	if httpClient == nil {
 		// Only build a transport if we're also building the client
 		tr := &http.Transport{
-			TLSClientConfig:    tlsConfig,
-			DisableCompression: true,
+			TLSClientConfig:     tlsConfig,
+			DisableCompression:  configuration.DisableCompression,
+			MaxIdleConnsPerHost: configuration.MaxIdleConnsPerHost,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+			ForceAttemptHTTP2:   configuration.ForceAttemptHTTP2,
 		}
-		httpClient = &http.Client{Transport: tr, Timeout: time.Duration(HttpTimeout * time.Second)}
+		if configuration.IdleConnTimeout > 0 {
+			tr.IdleConnTimeout = configuration.IdleConnTimeout
+		}
+		if configuration.TlsHandshakeTimeout > 0 {
+			tr.TLSHandshakeTimeout = configuration.TlsHandshakeTimeout
+		}
+		// Timeouts are set per request, see Session.Timeout.
+		httpClient = &http.Client{Transport: tr}
 	}
 	session = &Session{
-		httpClient: httpClient,
-		ApiUrl:     configuration.Url,
-		AuthTicket: "",
-		CsrfToken:  "",
-		Headers:    http.Header{},
+		httpClient:      httpClient,
+		ApiUrl:          configuration.Url,
+		AuthTicket:      "",
+		CsrfToken:       "",
+		Headers:         http.Header{},
+		Timeout:         time.Duration(HttpTimeout * time.Second),
+		TransferTimeout: time.Duration(TransferTimeout * time.Second),
+	}
+	if configuration.Timeout > 0 {
+		session.Timeout = configuration.Timeout
+	}
+	if configuration.TransferTimeout > 0 {
+		session.TransferTimeout = configuration.TransferTimeout
 	}
+	session.transient = newTransientRetry(configuration.TransientRetries, configuration.TransientBackoff)
+	session.totpCode = configuration.TotpCallback
+	if session.totpCode == nil && configuration.TotpCode != "" {
+		totpCode := configuration.TotpCode
+		session.totpCode = func() (string, error) { return totpCode, nil }
+	}
+	if configuration.CircuitBreakerThreshold > 0 {
+		session.breaker = newCircuitBreaker(configuration.CircuitBreakerThreshold, configuration.CircuitBreakerCooldown)
+	}
+	for k, values := range configuration.Headers {
+		session.Headers[http.CanonicalHeaderKey(k)] = append([]string{}, values...)
+	}
+	userAgent := configuration.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	session.Headers.Set("User-Agent", userAgent)
 	return
 }
 
@@ -72,6 +140,12 @@ func ParamsToBody(params map[string]interface{}) (body []byte) {
 	for k, intrV := range params {
 		var v string
 		switch intrV.(type) {
+		// Lists are sent as repeated parameters.
+		case []string:
+			for _, item := range intrV.([]string) {
+				vals.Add(k, item)
+			}
+			continue
 		// Convert true/false bool to 1/0 string where Proxmox API can understand it.
 		case bool:
 			if intrV.(bool) {
@@ -99,8 +173,47 @@ func ResponseJSON(resp *http.Response) (jbody map[string]interface{}) {
 	return
 }
 
+// ErrTfaRequired - the user has two-factor authentication and no TOTP code is configured.
+var ErrTfaRequired = errors.New("login requires a second factor, set Configuration.TotpCode or TotpCallback")
+
 func (s *Session) Login(username string, password string) (err error) {
-	reqbody := ParamsToBody(map[string]interface{}{"username": username, "password": password})
+	dat, err := s.requestTicket(map[string]interface{}{"username": username, "password": password})
+	if err != nil {
+		return err
+	}
+	if needTfa, _ := toFloat(dat["NeedTFA"]); needTfa == 1 {
+		dat, err = s.completeTfa(username, dat)
+		if err != nil {
+			return err
+		}
+	}
+	return s.setTicket(dat)
+}
+
+// completeTfa - answer the TOTP challenge of a partial ticket (PVE 7+), returns the full login response.
+func (s *Session) completeTfa(username string, dat map[string]interface{}) (map[string]interface{}, error) {
+	if s.totpCode == nil {
+		return nil, ErrTfaRequired
+	}
+	challenge, _ := dat["ticket"].(string)
+	code, err := s.totpCode()
+	if err != nil {
+		return nil, err
+	}
+	dat, err = s.requestTicket(map[string]interface{}{
+		"username":      username,
+		"password":      "totp:" + code,
+		"tfa-challenge": challenge,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("second factor refused: %w", err)
+	}
+	return dat, nil
+}
+
+// requestTicket - POST /access/ticket, returns the data of the response.
+func (s *Session) requestTicket(params map[string]interface{}) (dat map[string]interface{}, err error) {
+	reqbody := ParamsToBody(params)
 	olddebug := *Debug
 	*Debug = false // don't share passwords in debug log
 	resp, err := s.Post("/access/ticket", nil, nil, &reqbody)
@@ -109,16 +222,26 @@ func (s *Session) Login(username string, password string) (err error) {
 		return
 	}
 	if resp == nil {
-		return errors.New("Login error reading response")
+		return nil, errors.New("Login error reading response")
 	}
 	dr, _ := httputil.DumpResponse(resp, true)
 	jbody := ResponseJSON(resp)
-	if jbody == nil || jbody["data"] == nil {
-		return fmt.Errorf("Invalid login response:\n-----\n%s\n-----", dr)
+	dat, ok := jbody["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid login response:\n-----\n%s\n-----", dr)
 	}
-	dat := jbody["data"].(map[string]interface{})
-	s.AuthTicket = dat["ticket"].(string)
-	s.CsrfToken = dat["CSRFPreventionToken"].(string)
+	return dat, nil
+}
+
+// setTicket - keep the ticket and CSRF token of a login response.
+func (s *Session) setTicket(dat map[string]interface{}) error {
+	ticket, _ := dat["ticket"].(string)
+	csrfToken, _ := dat["CSRFPreventionToken"].(string)
+	if ticket == "" || csrfToken == "" {
+		return errors.New("login response has no ticket")
+	}
+	s.AuthTicket = ticket
+	s.CsrfToken = csrfToken
 	return nil
 }
 
@@ -130,32 +253,132 @@ func (s *Session) NewRequest(method, url string, headers *http.Header, body io.R
 	if headers != nil {
 		req.Header = *headers
 	}
-	if s.AuthTicket != "" {
+	s.setAuthHeaders(req)
+	return
+}
+
+// SetApiToken - authenticate requests with an API token instead of a ticket.
+func (s *Session) SetApiToken(tokenId string, secret string) {
+	s.ApiToken = tokenId + "=" + secret
+	s.AuthTicket = ""
+	s.CsrfToken = ""
+}
+
+func (s *Session) setAuthHeaders(req *http.Request) {
+	if s.ApiToken != "" {
+		req.Header.Set("Authorization", "PVEAPIToken="+s.ApiToken)
+	} else if s.AuthTicket != "" {
 		req.Header.Add("Cookie", "PVEAuthCookie="+s.AuthTicket)
 		req.Header.Add("CSRFPreventionToken", s.CsrfToken)
 	}
+}
+
+// setSessionHeaders - add the session headers (User-Agent, configured extra headers), headers
+// already set on the request take precedence.
+func (s *Session) setSessionHeaders(req *http.Request) {
+	for k, values := range s.Headers {
+		if _, isSet := req.Header[k]; !isSet {
+			req.Header[k] = values
+		}
+	}
+}
+
+// withTimeout - copy of the session whose requests use timeout.
+func (s *Session) withTimeout(timeout time.Duration) *Session {
+	session := *s
+	session.Timeout = timeout
+	return &session
+}
+
+// cancelOnClose - response body releasing the request timeout once read or closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body *cancelOnClose) Read(p []byte) (n int, err error) {
+	n, err = body.ReadCloser.Read(p)
+	if err == io.EOF {
+		body.cancel()
+	}
 	return
 }
 
+func (body *cancelOnClose) Close() error {
+	err := body.ReadCloser.Close()
+	body.cancel()
+	return err
+}
+
+// gzipBody - decompressed response body, closing the compressed one with it.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (body *gzipBody) Close() error {
+	body.Reader.Close()
+	return body.body.Close()
+}
+
 func (s *Session) Do(req *http.Request) (*http.Response, error) {
-	// Add session headers
-	for k := range s.Headers {
-		req.Header.Set(k, s.Headers.Get(k))
+	s.setSessionHeaders(req)
+
+	// The timeout covers reading the body, it is released by cancelOnClose.
+	cancel := context.CancelFunc(func() {})
+	if _, hasDeadline := req.Context().Deadline(); !hasDeadline && s.Timeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), s.Timeout)
+		req = req.WithContext(ctx)
 	}
 
 	if *Debug {
-		d, _ := httputil.DumpRequestOut(req, true)
+		// Bodies that cannot be read again (uploads) are left out.
+		d, _ := httputil.DumpRequestOut(req, req.Body == nil || req.GetBody != nil)
 		log.Println(">>>>>>>>>> REQUEST:", string(d))
 	}
 
+	target := breakerTarget(req.URL.Path)
+	if s.breaker != nil {
+		err := s.breaker.allow(target)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
 	resp, err := s.httpClient.Do(req)
 
+	if s.breaker != nil {
+		s.breaker.record(target, err != nil || isUnavailableStatus(resp.StatusCode))
+	}
+
 	if err != nil {
+		cancel()
 		return nil, err
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return nil, &ApiError{resp.StatusCode, resp.Status}
+		cancel()
+		apiErrors := readApiErrors(resp.Body)
+		resp.Body.Close()
+		return nil, checkVmLocked(&ApiError{Code: resp.StatusCode, Message: resp.Status, Errors: apiErrors})
+	}
+	resp.Body = &cancelOnClose{resp.Body, cancel}
+
+	// The transport only decompresses transparently when it asked for gzip itself,
+	// not when Accept-Encoding was set by the caller.
+	if !resp.Uncompressed && resp.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		resp.Body = &gzipBody{gzipReader, resp.Body}
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
 	}
 
 	if *Debug {
@@ -166,13 +389,45 @@ func (s *Session) Do(req *http.Request) (*http.Response, error) {
 	return resp, nil
 }
 
-// Perform a simple get to an endpoint
+// bufferBody - read the whole response body and close it, so that the request timeout is released
+// even when the caller never reads nor closes the body.
+func bufferBody(resp *http.Response) error {
+	rbody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(rbody))
+	return nil
+}
+
+// Perform a simple get to an endpoint, the response body is buffered (see StreamRequest).
 func (s *Session) Request(
 	method string,
 	url string,
 	params *url.Values,
 	headers *http.Header,
 	body *[]byte,
+) (resp *http.Response, err error) {
+	resp, err = s.StreamRequest(method, url, params, headers, body)
+	if err != nil {
+		return nil, err
+	}
+	err = bufferBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// StreamRequest - Request whose response body is read as it arrives, the caller must read or
+// close it to release the request timeout.
+func (s *Session) StreamRequest(
+	method string,
+	url string,
+	params *url.Values,
+	headers *http.Header,
+	body *[]byte,
 ) (resp *http.Response, err error) {
 	// add params to url here
 	url = s.ApiUrl + url
@@ -180,25 +435,30 @@ func (s *Session) Request(
 		url = url + "?" + params.Encode()
 	}
 
-	// Get the body if one is present
-	var buf io.Reader
-	if body != nil {
-		buf = bytes.NewReader(*body)
-	}
+	// Lock contention errors of reads are retried here, writes are retried by the task operations
+	// (retryTransientTask) so each error is only retried by one layer.
+	for attempt := 0; ; attempt++ {
+		// Get the body if one is present
+		var buf io.Reader
+		if body != nil {
+			buf = bytes.NewReader(*body)
+		}
 
-	req, err := s.NewRequest(method, url, headers, buf)
-	if err != nil {
-		return nil, err
-	}
+		req, err := s.NewRequest(method, url, headers, buf)
+		if err != nil {
+			return nil, err
+		}
 
-	req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept", "application/json")
 
-	resp, err = s.Do(req)
-	if err != nil {
-		return nil, err
+		resp, err = s.Do(req)
+		if err == nil {
+			return resp, nil
+		}
+		if method != "GET" || !IsTransient(err) || !s.transient.wait(attempt) {
+			return nil, err
+		}
 	}
-
-	return resp, nil
 }
 
 // Perform a simple get to an endpoint and unmarshall returned JSON
@@ -300,6 +560,31 @@ func (s *Session) PostJSON(
 	return s.RequestJSON("POST", url, params, headers, body, responseContainer)
 }
 
+// PostStream - POST a body read as it is sent, e.g. a file upload, without retries. contentLength
+// is the size of the body, -1 when unknown (the body is then sent chunked).
+func (s *Session) PostStream(
+	url string,
+	headers *http.Header,
+	body io.Reader,
+	contentLength int64,
+) (resp *http.Response, err error) {
+	req, err := s.NewRequest("POST", s.ApiUrl+url, headers, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = contentLength
+	req.Header.Set("Accept", "application/json")
+	resp, err = s.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	err = bufferBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 func (s *Session) Put(
 	url string,
 	params *url.Values,
@@ -0,0 +1,53 @@
+package proxmox
+
+import (
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionGzipResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		writer := gzip.NewWriter(w)
+		writer.Write([]byte(`{"data":{"version":"8.2.4"}}`))
+		writer.Close()
+	}))
+	defer server.Close()
+
+	session, err := NewSession(&Configuration{Url: server.URL}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Set by the caller, the transport leaves decompression to the session.
+	headers := http.Header{"Accept-Encoding": {"gzip"}}
+	var response map[string]interface{}
+	_, err = session.RequestJSON("GET", "/version", nil, &headers, nil, &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := response["data"].(map[string]interface{})
+	if version := GetString(data, "version"); version != "8.2.4" {
+		t.Errorf("version = %q, want %q", version, "8.2.4")
+	}
+}
+
+func TestSessionGzipResponseInvalid(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write([]byte("not gzip"))
+	}))
+	defer server.Close()
+
+	session, err := NewSession(&Configuration{Url: server.URL}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := http.Header{"Accept-Encoding": {"gzip"}}
+	_, err = session.Request("GET", "/version", nil, &headers, nil)
+	if err == nil {
+		t.Fatal("expected an error for a body that is not gzip")
+	}
+}
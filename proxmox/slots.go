@@ -0,0 +1,76 @@
+package proxmox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Number of devices each controller accepts, CD-ROMs cannot be attached to virtio.
+var controllerSlots = map[string]int{
+	ConfigPrefixIde:  4,
+	ConfigPrefixSata: 6,
+	ConfigPrefixScsi: 31,
+}
+
+// Buses tried for CD-ROM and cloud-init drives when none is given.
+var defaultDriveBuses = []string{ConfigPrefixIde, ConfigPrefixSata, ConfigPrefixScsi}
+
+// FreeDriveSlot - first device name (ide3, sata0...) not used in vmConfig, trying buses in order
+// (ide, sata then scsi when none is given).
+func FreeDriveSlot(vmConfig map[string]interface{}, buses ...string) (device string, err error) {
+	if len(buses) == 0 {
+		buses = defaultDriveBuses
+	}
+	for _, bus := range buses {
+		slots, ok := controllerSlots[bus]
+		if !ok {
+			return "", fmt.Errorf("bus '%s' does not accept CD-ROM drives", bus)
+		}
+		for slot := 0; slot < slots; slot++ {
+			device = fmt.Sprintf("%s%d", bus, slot)
+			if _, isSet := vmConfig[device]; !isSet {
+				return device, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no free slot on %s", strings.Join(buses, ", "))
+}
+
+// FindFreeDriveSlot - first free device of the VM on buses, pending changes count as used.
+func (c *Client) FindFreeDriveSlot(vmr *VmRef, buses ...string) (device string, err error) {
+	pendingConfig, err := c.GetVmPendingConfig(vmr)
+	if err != nil {
+		return "", err
+	}
+	vmConfig := map[string]interface{}{}
+	for key, value := range pendingConfig {
+		vmConfig[key] = value
+	}
+	return FreeDriveSlot(vmConfig, buses...)
+}
+
+// AttachIso - Insert iso (`local:iso/debian.iso`) in a new CD-ROM drive on the first free slot of buses.
+func (c *Client) AttachIso(vmr *VmRef, iso string, buses ...string) (device string, err error) {
+	device, err = c.FindFreeDriveSlot(vmr, buses...)
+	if err != nil {
+		return "", err
+	}
+	_, err = c.setGuestConfig(vmr, map[string]interface{}{device: iso + ",media=cdrom"})
+	if err != nil {
+		return "", err
+	}
+	return
+}
+
+// AttachCloudInitDrive - Add a cloud-init drive allocated on storage on the first free slot of buses.
+func (c *Client) AttachCloudInitDrive(vmr *VmRef, storage string, buses ...string) (device string, err error) {
+	device, err = c.FindFreeDriveSlot(vmr, buses...)
+	if err != nil {
+		return "", err
+	}
+	_, err = c.setGuestConfig(vmr, map[string]interface{}{device: storage + ":cloudinit"})
+	if err != nil {
+		return "", err
+	}
+	return
+}
@@ -0,0 +1,193 @@
+package proxmox
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot - snapshot of a guest, VmState is set when the RAM of a VM was saved with it.
+type Snapshot struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	SnapTime    FlexInt  `json:"snaptime"`
+	Parent      string   `json:"parent"`
+	VmState     FlexBool `json:"vmstate"`
+}
+
+func snapshotUrl(vmr *VmRef) string {
+	return ApiPath("nodes", vmr.node, vmr.vmType, vmr.vmId, "snapshot")
+}
+
+// ListSnapshots - Snapshots of the guest, oldest first. The `current` pseudo snapshot is left out.
+func (c *Client) ListSnapshots(vmr *VmRef) (snapshots []Snapshot, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := getApiData[[]Snapshot](c, snapshotUrl(vmr))
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshot := range entries {
+		if snapshot.Name != "current" {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].SnapTime < snapshots[j].SnapTime
+	})
+	return
+}
+
+// CreateSnapshot - Snapshot the guest, vmstate also saves the RAM of a running VM.
+func (c *Client) CreateSnapshot(vmr *VmRef, name string, description string, vmstate bool) (exitStatus string, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return "", err
+	}
+	params := map[string]interface{}{"snapname": name}
+	if description != "" {
+		params["description"] = description
+	}
+	if vmstate && vmr.vmType == "qemu" {
+		params["vmstate"] = true
+	}
+	reqbody := ParamsToBody(params)
+	resp, err := c.session.Post(snapshotUrl(vmr), nil, nil, &reqbody)
+	if err == nil {
+		taskResponse := ResponseJSON(resp)
+		exitStatus, err = c.WaitForCompletion(taskResponse)
+	}
+	return
+}
+
+// DeleteSnapshot - Remove a snapshot of the guest.
+func (c *Client) DeleteSnapshot(vmr *VmRef, name string) (exitStatus string, err error) {
+	err = c.CheckVmRef(vmr)
+	if err != nil {
+		return "", err
+	}
+	var taskResponse map[string]interface{}
+	_, err = c.session.RequestJSON("DELETE", snapshotUrl(vmr)+ApiPath(name), nil, nil, nil, &taskResponse)
+	if err != nil {
+		return "", err
+	}
+	return c.WaitForCompletion(taskResponse)
+}
+
+// Proxmox has no endpoint snapshotting a single volume, snapshots always cover every volume of
+// the guest. GuestVolumes lets tooling check which volumes a snapshot can include: a guest
+// snapshot fails when one of its volumes is on a storage without snapshot support. Volumes can
+// be kept out of guest snapshots by moving them to a separate guest or detaching them first.
+
+// GuestVolume - volume attached to a guest.
+type GuestVolume struct {
+	Key         string // config key, scsi0, rootfs, mp1...
+	Volid       string
+	StorageType string
+	// Can the volume be snapshotted (storage and format support)?
+	Snapshots bool
+}
+
+// Options of a guest config holding volumes, also restored from backup archives.
+var rxGuestVolume = regexp.MustCompile(`^((ide|sata|scsi|virtio)\d+|efidisk0|tpmstate0|rootfs|mp\d+)$`)
+
+// Storage types with native volume snapshots, file storages support snapshots of qcow2 volumes.
+var snapshotStorageTypes = []string{"zfspool", "rbd", "lvmthin", "btrfs", "cephfs"}
+var fileStorageTypes = []string{"dir", "nfs", "cifs", "glusterfs"}
+
+// volumeSupportsSnapshots - can a volume of storageType be snapshotted?
+func volumeSupportsSnapshots(storageType string, volumeName string) bool {
+	if inArray(snapshotStorageTypes, storageType) {
+		return true
+	}
+	return inArray(fileStorageTypes, storageType) && path.Ext(volumeName) == ".qcow2"
+}
+
+// GuestVolumes - Volumes of the guest with their snapshot support, CD-ROMs and bind mounts are left out.
+func (c *Client) GuestVolumes(vmr *VmRef) (volumes []GuestVolume, err error) {
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return nil, err
+	}
+	storageTypes := map[string]string{}
+	for key, value := range vmConfig {
+		conf, ok := value.(string)
+		if !ok || !rxGuestVolume.MatchString(key) || strings.Contains(conf, "media=cdrom") {
+			continue
+		}
+		volid := strings.Split(conf, ",")[0]
+		volid = strings.TrimPrefix(volid, "volume=")
+		storageName, volumeName := getStorageAndVolumeName(volid, ":")
+		if storageName == "" || strings.HasPrefix(volid, "/") {
+			continue
+		}
+		storageType, isSet := storageTypes[storageName]
+		if !isSet {
+			storageType, err = c.GetStorageType(storageName)
+			if err != nil {
+				return nil, err
+			}
+			storageTypes[storageName] = storageType
+		}
+		volumes = append(volumes, GuestVolume{
+			Key:         key,
+			Volid:       volid,
+			StorageType: storageType,
+			Snapshots:   volumeSupportsSnapshots(storageType, volumeName),
+		})
+	}
+	sort.Slice(volumes, func(i, j int) bool {
+		return volumes[i].Key < volumes[j].Key
+	})
+	return
+}
+
+// PruneSnapshots - Apply a retention policy to the guest snapshots named with namePrefix: the
+// keepLast most recent ones are kept, plus the most recent one of each of the keepDaily last days
+// having snapshots. The others are deleted oldest first, each delete task is waited for.
+// Returns the names of the deleted snapshots.
+func (c *Client) PruneSnapshots(vmr *VmRef, keepLast int, keepDaily int, namePrefix string) (deleted []string, err error) {
+	snapshots, err := c.ListSnapshots(vmr)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []Snapshot
+	for _, snapshot := range snapshots {
+		if strings.HasPrefix(snapshot.Name, namePrefix) {
+			candidates = append(candidates, snapshot)
+		}
+	}
+	// Newest first to pick the snapshots to keep.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].SnapTime > candidates[j].SnapTime
+	})
+	keep := map[string]bool{}
+	days := map[string]bool{}
+	for i, snapshot := range candidates {
+		if i < keepLast {
+			keep[snapshot.Name] = true
+		}
+		day := time.Unix(int64(snapshot.SnapTime), 0).Format("2006-01-02")
+		if !days[day] && len(days) < keepDaily {
+			days[day] = true
+			keep[snapshot.Name] = true
+		}
+	}
+	for i := len(candidates) - 1; i >= 0; i-- {
+		name := candidates[i].Name
+		if keep[name] {
+			continue
+		}
+		_, err = c.DeleteSnapshot(vmr, name)
+		if err != nil {
+			return deleted, fmt.Errorf("deleting snapshot '%s': %w", name, err)
+		}
+		deleted = append(deleted, name)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,313 @@
+package proxmox
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DeleteVolume - Delete a storage volume, volid is in the `storage:volume` form.
+func (c *Client) DeleteVolume(node string, volid string) (exitStatus string, err error) {
+	storageName, volumeName := getStorageAndVolumeName(volid, ":")
+	url := ApiPath("nodes", node, "storage", storageName, "content", volumeName)
+	var taskResponse map[string]interface{}
+	_, err = c.session.RequestJSON("DELETE", url, nil, nil, nil, &taskResponse)
+	if err != nil {
+		return "", err
+	}
+	return c.WaitForCompletion(taskResponse)
+}
+
+// GetVolumeInfo - Get volume attributes (path, size, used, format).
+func (c *Client) GetVolumeInfo(node string, volid string) (volumeInfo map[string]interface{}, err error) {
+	storageName, volumeName := getStorageAndVolumeName(volid, ":")
+	url := ApiPath("nodes", node, "storage", storageName, "content", volumeName)
+	volumeInfo, err = getApiData[map[string]interface{}](c, url)
+	if err == nil && volumeInfo == nil {
+		return nil, errors.New("Volume INFO not readable")
+	}
+	return
+}
+
+// CopyVolume - Copy a volume to the target volid, optionally on another node (empty targetNode means same node).
+func (c *Client) CopyVolume(node string, volid string, target string, targetNode string) (exitStatus string, err error) {
+	storageName, volumeName := getStorageAndVolumeName(volid, ":")
+	params := map[string]interface{}{
+		"target": target,
+	}
+	if targetNode != "" {
+		params["target_node"] = targetNode
+	}
+	reqbody := ParamsToBody(params)
+	url := ApiPath("nodes", node, "storage", storageName, "content", volumeName)
+	resp, err := c.session.Post(url, nil, nil, &reqbody)
+	if err == nil {
+		taskResponse := ResponseJSON(resp)
+		exitStatus, err = c.WaitForCompletion(taskResponse)
+	}
+	return
+}
+
+// Upload - Upload a file to storage, contentType is the storage content type (iso, vztmpl, snippets...).
+// The file is streamed, its size is only known when it is seekable (files), other readers are sent chunked.
+func (c *Client) Upload(node string, storage string, contentType string, filename string, file io.Reader) (err error) {
+	pipeReader, pipeWriter := io.Pipe()
+	writer := multipart.NewWriter(pipeWriter)
+	contentLength := int64(-1)
+	if size := readerSize(file); size >= 0 {
+		contentLength, err = uploadOverhead(writer.Boundary(), contentType, filename)
+		if err != nil {
+			return err
+		}
+		contentLength += size
+	}
+	go func() {
+		pipeWriter.CloseWithError(writeUploadForm(writer, contentType, filename, file))
+	}()
+	// Stops the form writer when the request ends before reading the whole form.
+	defer pipeReader.Close()
+
+	headers := &http.Header{}
+	headers.Add("Content-Type", writer.FormDataContentType())
+	url := ApiPath("nodes", node, "storage", storage, "upload")
+	resp, err := c.session.withTimeout(c.session.TransferTimeout).PostStream(url, headers, pipeReader, contentLength)
+	if err != nil {
+		return err
+	}
+	// Recent releases answer with a task, older ones upload synchronously.
+	taskResponse := ResponseJSON(resp)
+	if _, isTask := taskResponse["data"].(string); isTask {
+		_, err = c.WaitForCompletion(taskResponse)
+	}
+	return
+}
+
+// writeUploadForm - multipart form of an upload, file being the last field.
+func writeUploadForm(writer *multipart.Writer, contentType string, filename string, file io.Reader) error {
+	err := writer.WriteField("content", contentType)
+	if err != nil {
+		return err
+	}
+	part, err := writer.CreateFormFile("filename", filename)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, file)
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// uploadOverhead - size of the upload form with boundary, without the file content.
+func uploadOverhead(boundary string, contentType string, filename string) (size int64, err error) {
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	err = writer.SetBoundary(boundary)
+	if err != nil {
+		return 0, err
+	}
+	err = writeUploadForm(writer, contentType, filename, bytes.NewReader(nil))
+	return int64(form.Len()), err
+}
+
+// readerSize - bytes left to read from file, -1 when it is not seekable.
+func readerSize(file io.Reader) int64 {
+	if seeker, ok := file.(io.Seeker); ok {
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err = seeker.Seek(offset, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - offset
+	}
+	return -1
+}
+
+// GetStorageType - Get the type of a storage (dir, lvm, lvmthin, zfspool, nfs, rbd...).
+func (c *Client) GetStorageType(storage string) (storageType string, err error) {
+	storageConfig, err := getApiData[map[string]interface{}](c, ApiPath("storage", storage))
+	if err != nil {
+		return "", err
+	}
+	if storageConfig == nil {
+		return "", errors.New("Storage CONFIG not readable")
+	}
+	return GetString(storageConfig, "type"), nil
+}
+
+// GetStorageContent - List volumes of a storage on node, optionally filtered by owner vmid (0 lists all).
+func (c *Client) GetStorageContent(node string, storage string, vmid int) (content []map[string]interface{}, err error) {
+	params := url.Values{}
+	if vmid > 0 {
+		params.Set("vmid", strconv.Itoa(vmid))
+	}
+	return getApiDataWithParams[[]map[string]interface{}](c, ApiPath("nodes", node, "storage", storage, "content"), params)
+}
+
+// Storage types whose volumes are block devices named without directory nor extension.
+var blockStorageTypes = []string{"lvm", "lvmthin", "zfspool", "zfs", "rbd", "iscsi", "iscsidirect"}
+
+// DiskName - volume name of disk number diskID, `vm-100-disk-0` on block storages and
+// `100/vm-100-disk-0.qcow2` on file storages (format defaults to qcow2).
+func DiskName(storageType string, vmid int, diskID int, format string) string {
+	if inArray(blockStorageTypes, storageType) {
+		return fmt.Sprintf("vm-%d-disk-%d", vmid, diskID)
+	}
+	if format == "" {
+		format = "qcow2"
+	}
+	return fmt.Sprintf("%d/vm-%d-disk-%d.%s", vmid, vmid, diskID, format)
+}
+
+// NextFreeDiskName - first free disk volid for vmid on storage, in the naming scheme of the storage type.
+func (c *Client) NextFreeDiskName(node string, storage string, vmid int, format string) (volid string, err error) {
+	storageType, err := c.GetStorageType(storage)
+	if err != nil {
+		return "", err
+	}
+	content, err := c.GetStorageContent(node, storage, vmid)
+	if err != nil {
+		return "", err
+	}
+	used := map[string]bool{}
+	for _, volume := range content {
+		_, volumeName := getStorageAndVolumeName(GetString(volume, "volid"), ":")
+		// Compare without extension, vm-100-disk-0.raw blocks vm-100-disk-0.qcow2.
+		used[strings.TrimSuffix(volumeName, path.Ext(volumeName))] = true
+	}
+	for diskID := 0; ; diskID++ {
+		volumeName := DiskName(storageType, vmid, diskID, format)
+		if !used[strings.TrimSuffix(volumeName, path.Ext(volumeName))] {
+			return storage + ":" + volumeName, nil
+		}
+	}
+}
+
+// DownloadUrl - Make node download a file from url to storage, contentType is iso, vztmpl or import.
+func (c *Client) DownloadUrl(node string, storage string, contentType string, filename string, fileUrl string) (exitStatus string, err error) {
+	reqbody := ParamsToBody(map[string]interface{}{
+		"content":  contentType,
+		"filename": filename,
+		"url":      fileUrl,
+	})
+	url := ApiPath("nodes", node, "storage", storage, "download-url")
+	resp, err := c.session.Post(url, nil, nil, &reqbody)
+	if err == nil {
+		taskResponse := ResponseJSON(resp)
+		exitStatus, err = c.WaitForCompletion(taskResponse)
+	}
+	return
+}
+
+// StorageCandidate - storage available on a node with its free space in bytes.
+type StorageCandidate struct {
+	Storage string
+	Node    string
+	Type    string
+	Content []string
+	Shared  bool
+	Total   int64
+	Used    int64
+	Avail   int64
+}
+
+// FindStorages - active storages accepting contentType (images, rootdir, iso, vztmpl, backup, snippets, import),
+// on node or on every online node when node is empty, sorted by free space.
+// Shared storages are listed once.
+func (c *Client) FindStorages(contentType string, node string, sharedOnly bool) (candidates []StorageCandidate, err error) {
+	nodes := []string{node}
+	if node == "" {
+		nodes, err = c.getOnlineNodes()
+		if err != nil {
+			return nil, err
+		}
+	}
+	seenShared := map[string]bool{}
+	for _, nodeName := range nodes {
+		params := url.Values{"content": {contentType}, "enabled": {"1"}}
+		entries, err := getApiDataWithParams[[]map[string]interface{}](c, ApiPath("nodes", nodeName, "storage"), params)
+		if err != nil {
+			return nil, err
+		}
+		for _, storageMap := range entries {
+			if GetIntDefault(storageMap, "active", 1) != 1 {
+				continue
+			}
+			candidate := StorageCandidate{
+				Storage: GetString(storageMap, "storage"),
+				Node:    nodeName,
+				Type:    GetString(storageMap, "type"),
+				Content: strings.Split(GetString(storageMap, "content"), ","),
+				Shared:  GetIntDefault(storageMap, "shared", 0) == 1,
+				Total:   int64(GetFloatDefault(storageMap, "total", 0)),
+				Used:    int64(GetFloatDefault(storageMap, "used", 0)),
+				Avail:   int64(GetFloatDefault(storageMap, "avail", 0)),
+			}
+			if sharedOnly && !candidate.Shared {
+				continue
+			}
+			if candidate.Shared {
+				if seenShared[candidate.Storage] {
+					continue
+				}
+				seenShared[candidate.Storage] = true
+			}
+			candidates = append(candidates, candidate)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Avail > candidates[j].Avail
+	})
+	return
+}
+
+// CreateStorage - Add a storage definition to the cluster, params are those of POST /storage (storage, type...).
+func (c *Client) CreateStorage(params map[string]interface{}) (err error) {
+	reqbody := ParamsToBody(params)
+	_, err = c.session.Post("/storage", nil, nil, &reqbody)
+	return
+}
+
+// ClusterStorage - storage entry of /cluster/resources, one per node even for shared storages.
+// Disk and MaxDisk are the used and total bytes.
+type ClusterStorage struct {
+	Id         string   `json:"id"`
+	Storage    string   `json:"storage"`
+	Node       string   `json:"node"`
+	PluginType string   `json:"plugintype"`
+	Content    string   `json:"content"`
+	Shared     FlexBool `json:"shared"`
+	Status     string   `json:"status"`
+	Disk       FlexInt  `json:"disk"`
+	MaxDisk    FlexInt  `json:"maxdisk"`
+}
+
+// ContentTypes - content types accepted by the storage (images, rootdir, iso...).
+func (storage ClusterStorage) ContentTypes() []string {
+	return strings.Split(storage.Content, ",")
+}
+
+// Avail - free bytes of the storage.
+func (storage ClusterStorage) Avail() int64 {
+	return int64(storage.MaxDisk - storage.Disk)
+}
+
+// ListClusterStorages - typed listing of the storages of every node of the cluster.
+func (c *Client) ListClusterStorages() (storages []ClusterStorage, err error) {
+	return getApiData[[]ClusterStorage](c, "/cluster/resources?type=storage")
+}
@@ -0,0 +1,63 @@
+package proxmox
+
+import (
+	"strings"
+)
+
+// IscsiLun - LUN of an iSCSI storage, Volid is usable as disk file or as base of an LVM storage.
+type IscsiLun struct {
+	Volid string
+	Size  int64
+}
+
+// CreateIscsiStorage - Add an iSCSI storage for target on portal, restricted to nodes when not empty.
+// With useLunsDirectly LUNs can be used as VM disks, otherwise the storage only serves as base for LVM.
+func (c *Client) CreateIscsiStorage(storage string, portal string, target string, nodes []string, useLunsDirectly bool) (err error) {
+	content := "none"
+	if useLunsDirectly {
+		content = "images"
+	}
+	params := map[string]interface{}{
+		"storage": storage,
+		"type":    "iscsi",
+		"portal":  portal,
+		"target":  target,
+		"content": content,
+	}
+	if len(nodes) > 0 {
+		params["nodes"] = strings.Join(nodes, ",")
+	}
+	return c.CreateStorage(params)
+}
+
+// ListIscsiLuns - LUNs discovered by node on an iSCSI storage.
+func (c *Client) ListIscsiLuns(node string, storage string) (luns []IscsiLun, err error) {
+	content, err := c.GetStorageContent(node, storage, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, volume := range content {
+		luns = append(luns, IscsiLun{
+			Volid: GetString(volume, "volid"),
+			Size:  int64(GetFloatDefault(volume, "size", 0)),
+		})
+	}
+	return
+}
+
+// CreateLvmOverIscsi - Create a shared LVM storage whose volume group vgname is created on an iSCSI LUN.
+// The LUN must be unused, Proxmox initializes the volume group on it.
+func (c *Client) CreateLvmOverIscsi(storage string, lunVolid string, vgname string, nodes []string) (err error) {
+	params := map[string]interface{}{
+		"storage": storage,
+		"type":    "lvm",
+		"base":    lunVolid,
+		"vgname":  vgname,
+		"shared":  true,
+		"content": "images,rootdir",
+	}
+	if len(nodes) > 0 {
+		params["nodes"] = strings.Join(nodes, ",")
+	}
+	return c.CreateStorage(params)
+}
@@ -0,0 +1,81 @@
+package proxmox
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUploadStreamsForm(t *testing.T) {
+	content := strings.Repeat("iso image ", 100000)
+	for _, test := range []struct {
+		name          string
+		file          io.Reader
+		contentLength int64
+	}{
+		{"seekable", bytes.NewReader([]byte(content)), int64(len(content))},
+		{"stream", io.MultiReader(strings.NewReader(content)), -1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.contentLength >= 0 && r.ContentLength <= test.contentLength {
+					t.Errorf("Content-Length = %d, want the file size %d and the form", r.ContentLength, test.contentLength)
+				}
+				if test.contentLength < 0 && r.ContentLength != -1 {
+					t.Errorf("Content-Length = %d, want unknown", r.ContentLength)
+				}
+				if r.URL.Path != "/nodes/pve/storage/local/upload" {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				// A wrong Content-Length fails the form parsing.
+				file, header, err := r.FormFile("filename")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				received, _ := io.ReadAll(file)
+				if header.Filename != "debian.iso" || string(received) != content {
+					t.Errorf("received %s of %d bytes", header.Filename, len(received))
+				}
+				if r.FormValue("content") != "iso" {
+					t.Errorf("content = %q", r.FormValue("content"))
+				}
+				w.Write([]byte(`{"data":null}`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(&Configuration{Url: server.URL}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = client.Upload("pve", "local", "iso", "debian.iso", test.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestDiskName(t *testing.T) {
+	for _, test := range []struct {
+		storageType string
+		vmid        int
+		diskID      int
+		format      string
+		want        string
+	}{
+		{"lvmthin", 100, 0, "", "vm-100-disk-0"},
+		{"zfspool", 100, 3, "raw", "vm-100-disk-3"},
+		{"rbd", 2001, 1, "", "vm-2001-disk-1"},
+		{"dir", 100, 0, "", "100/vm-100-disk-0.qcow2"},
+		{"nfs", 100, 2, "raw", "100/vm-100-disk-2.raw"},
+		{"cifs", 105, 1, "vmdk", "105/vm-105-disk-1.vmdk"},
+	} {
+		if got := DiskName(test.storageType, test.vmid, test.diskID, test.format); got != test.want {
+			t.Errorf("DiskName(%q, %d, %d, %q) = %q, want %q", test.storageType, test.vmid, test.diskID, test.format, got, test.want)
+		}
+	}
+}
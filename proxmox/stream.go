@@ -0,0 +1,178 @@
+package proxmox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Task logs, task lists and the syslog can be huge, they are fetched page by page and each
+// page is decoded element by element from the response body instead of being buffered.
+
+// defaultPageSize - elements fetched per request by the log and task list helpers.
+const defaultPageSize = 1000
+
+// LogLine - line of a task log or of the syslog, N is the line number.
+type LogLine struct {
+	N int    `json:"n"`
+	T string `json:"t"`
+}
+
+// StreamJSON - GET url and call fn with each element of the `data` array of the response
+// as it is decoded, returns the `total` field when present (-1 otherwise).
+func (s *Session) StreamJSON(url string, params *url.Values, fn func(element json.RawMessage) error) (total int, err error) {
+	resp, err := s.StreamRequest("GET", url, params, nil, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer resp.Body.Close()
+
+	total = -1
+	decoder := json.NewDecoder(resp.Body)
+	err = expectDelim(decoder, '{')
+	if err != nil {
+		return -1, err
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return -1, err
+		}
+		switch token {
+		case "data":
+			err = expectDelim(decoder, '[')
+			if err != nil {
+				return -1, err
+			}
+			for decoder.More() {
+				var element json.RawMessage
+				err = decoder.Decode(&element)
+				if err != nil {
+					return -1, err
+				}
+				err = fn(element)
+				if err != nil {
+					return -1, err
+				}
+			}
+			err = expectDelim(decoder, ']')
+		case "total":
+			var value FlexInt
+			err = decoder.Decode(&value)
+			total = int(value)
+		default:
+			var skipped json.RawMessage
+			err = decoder.Decode(&skipped)
+		}
+		if err != nil {
+			return -1, err
+		}
+	}
+	return total, nil
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("unexpected JSON token %v, expected %v", token, delim)
+	}
+	return nil
+}
+
+// Paginate - call fn with each element of a collection accepting start/limit, fetched
+// pageSize elements at a time from offset start. Traversal ends on a short page or once
+// the `total` reported by the endpoint is reached. fn can return an error to stop, which is then returned.
+func (c *Client) Paginate(path string, params url.Values, start int, pageSize int, fn func(element json.RawMessage) error) (err error) {
+	if pageSize <= 0 {
+		return errors.New("page size must be positive")
+	}
+	// Copied, start and limit must not leak into the caller's values.
+	pageParams := url.Values{}
+	for k, values := range params {
+		pageParams[k] = values
+	}
+	for {
+		pageParams.Set("start", strconv.Itoa(start))
+		pageParams.Set("limit", strconv.Itoa(pageSize))
+		elements := 0
+		total, err := c.session.StreamJSON(path, &pageParams, func(element json.RawMessage) error {
+			elements++
+			return fn(element)
+		})
+		if err != nil {
+			return err
+		}
+		start += elements
+		if elements < pageSize || (total >= 0 && start >= total) {
+			return nil
+		}
+	}
+}
+
+// streamLog - call fn with each line of a log endpoint accepting start/limit, from line start.
+func (c *Client) streamLog(path string, params url.Values, start int, fn func(line LogLine) error) (err error) {
+	return c.Paginate(path, params, start, defaultPageSize, func(element json.RawMessage) error {
+		var line LogLine
+		err := json.Unmarshal(element, &line)
+		if err != nil {
+			return err
+		}
+		return fn(line)
+	})
+}
+
+// StreamTaskLog - call fn with each line of the log of task upid, from line start.
+// fn can return an error to stop, which is then returned.
+func (c *Client) StreamTaskLog(upid string, start int, fn func(line LogLine) error) (err error) {
+	nodeMatch := rxTaskNode.FindStringSubmatch(upid)
+	if nodeMatch == nil {
+		return &ErrUnexpectedResponse{"malformed task UPID", upid}
+	}
+	path := ApiPath("nodes", nodeMatch[1], "tasks", upid, "log")
+	return c.streamLog(path, url.Values{}, start, fn)
+}
+
+// StreamSyslog - call fn with each syslog line of node, since and until are optional
+// `YYYY-MM-DD HH:MM:SS` bounds. fn can return an error to stop, which is then returned.
+func (c *Client) StreamSyslog(node string, since string, until string, fn func(line LogLine) error) (err error) {
+	params := url.Values{}
+	if since != "" {
+		params.Set("since", since)
+	}
+	if until != "" {
+		params.Set("until", until)
+	}
+	return c.streamLog(ApiPath("nodes", node, "syslog"), params, 0, fn)
+}
+
+// TaskEntry - task of a node task list.
+type TaskEntry struct {
+	Upid      string  `json:"upid"`
+	Node      string  `json:"node"`
+	Type      string  `json:"type"`
+	Id        string  `json:"id"`
+	User      string  `json:"user"`
+	Status    string  `json:"status"`
+	StartTime FlexInt `json:"starttime"`
+	EndTime   FlexInt `json:"endtime"`
+}
+
+// EachNodeTask - call fn with each task of node, most recent first. params are the optional
+// filters of /nodes/{node}/tasks (vmid, typefilter, userfilter, errors, source, since, until).
+// fn can return an error to stop, which is then returned.
+func (c *Client) EachNodeTask(node string, params url.Values, fn func(task TaskEntry) error) (err error) {
+	path := ApiPath("nodes", node, "tasks")
+	return c.Paginate(path, params, 0, defaultPageSize, func(element json.RawMessage) error {
+		var task TaskEntry
+		err := json.Unmarshal(element, &task)
+		if err != nil {
+			return err
+		}
+		return fn(task)
+	})
+}
@@ -0,0 +1,38 @@
+package proxmox
+
+import (
+	"sync"
+)
+
+// TaskResult - outcome of a task waited on by WaitForCompletions.
+type TaskResult struct {
+	Upid       string
+	ExitStatus string
+	Err        error
+}
+
+// WaitForCompletions - wait for many tasks at once, polling at most concurrency tasks in parallel.
+// Results are sent as tasks finish, the channel is closed once every task is done.
+func (c *Client) WaitForCompletions(upids []string, concurrency int) <-chan TaskResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make(chan TaskResult, len(upids))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, upid := range upids {
+		wg.Add(1)
+		go func(upid string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			exitStatus, err := c.WaitForCompletion(map[string]interface{}{"data": upid})
+			results <- TaskResult{Upid: upid, ExitStatus: exitStatus, Err: err}
+		}(upid)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
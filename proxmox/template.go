@@ -0,0 +1,159 @@
+package proxmox
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GetTemplates - all template VMs of the cluster.
+func (c *Client) GetTemplates() (templates []VmResource, err error) {
+	vms, err := c.GetVmResources()
+	if err != nil {
+		return nil, err
+	}
+	for _, vm := range vms {
+		if vm.Template && vm.Type == "qemu" {
+			templates = append(templates, vm)
+		}
+	}
+	return
+}
+
+// FindTemplate - resolve a template by name, or by tag when no template has that name.
+func (c *Client) FindTemplate(nameOrTag string) (vmr *VmRef, err error) {
+	templates, err := c.GetTemplates()
+	if err != nil {
+		return nil, err
+	}
+	var tagged *VmResource
+	for ii := range templates {
+		template := &templates[ii]
+		if template.Name == nameOrTag {
+			tagged = template
+			break
+		}
+		if tagged == nil && inArray(splitTags(template.Tags), nameOrTag) {
+			tagged = template
+		}
+	}
+	if tagged == nil {
+		return nil, fmt.Errorf("template '%s' not found", nameOrTag)
+	}
+	vmr = NewVmRef(int(tagged.VmId))
+	vmr.node = tagged.Node
+	vmr.vmType = tagged.Type
+	return
+}
+
+var rxBootDiskName = regexp.MustCompile(`^(scsi|virtio|sata|ide)\d+$`)
+
+// getBootDiskStorage - storage of the first disk of a VM, CD-ROMs and cloud-init drives excluded.
+func (c *Client) getBootDiskStorage(vmr *VmRef) (storage string, err error) {
+	vmConfig, err := c.GetVmConfig(vmr)
+	if err != nil {
+		return "", err
+	}
+	diskNames := []string{}
+	for k := range vmConfig {
+		if rxBootDiskName.MatchString(k) {
+			diskNames = append(diskNames, k)
+		}
+	}
+	sort.Strings(diskNames)
+	if bootOrder := ParseBootOrder(GetString(vmConfig, "boot")); len(bootOrder) > 0 {
+		diskNames = append(bootOrder, diskNames...)
+	}
+	for _, diskName := range diskNames {
+		if !rxBootDiskName.MatchString(diskName) {
+			continue
+		}
+		diskConf := GetString(vmConfig, diskName)
+		if diskConf == "" || strings.Contains(diskConf, "media=cdrom") || strings.Contains(diskConf, "cloudinit") {
+			continue
+		}
+		storage, _ = getStorageAndVolumeName(strings.Split(diskConf, ",")[0], ":")
+		return storage, nil
+	}
+	return "", fmt.Errorf("Vm '%d' has no disk", vmr.vmId)
+}
+
+// isSharedStorage - is storage shared between the cluster nodes?
+func (c *Client) isSharedStorage(storage string) (shared bool, err error) {
+	storages, err := getApiData[[]map[string]interface{}](c, "/cluster/resources?type=storage")
+	if err != nil {
+		return false, err
+	}
+	for _, storageMap := range storages {
+		if GetString(storageMap, "storage") == storage {
+			return GetIntDefault(storageMap, "shared", 0) == 1, nil
+		}
+	}
+	return false, fmt.Errorf("storage '%s' not found", storage)
+}
+
+// nodeWithMostFreeMemory - online node with the largest amount of free memory.
+func (c *Client) nodeWithMostFreeMemory() (node string, err error) {
+	nodes, err := c.GetNodes(NodeFilter{OnlineOnly: true})
+	if err != nil {
+		return "", err
+	}
+	bestFree := FlexInt(-1)
+	for _, nodeInfo := range nodes {
+		if free := nodeInfo.MaxMem - nodeInfo.Mem; free > bestFree {
+			bestFree = free
+			node = nodeInfo.Node
+		}
+	}
+	if node == "" {
+		return "", errors.New("no online node")
+	}
+	return
+}
+
+// CloneFromTemplate - Clone the template found by name or tag.
+// When config has no storage the template boot disk storage is used. The clone is placed on the
+// online node with the most free memory when that storage is shared, on the template node otherwise.
+func (c *Client) CloneFromTemplate(nameOrTag string, config ConfigQemu) (vmr *VmRef, err error) {
+	sourceVmr, err := c.FindTemplate(nameOrTag)
+	if err != nil {
+		return nil, err
+	}
+	storage := config.Storage
+	if disk0Storage, ok := config.QemuDisks[0]["storage"].(string); ok && len(disk0Storage) > 0 {
+		storage = disk0Storage
+	}
+	if storage == "" {
+		storage, err = c.getBootDiskStorage(sourceVmr)
+		if err != nil {
+			return nil, err
+		}
+		config.Storage = storage
+	}
+
+	node := sourceVmr.node
+	shared, err := c.isSharedStorage(storage)
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		node, err = c.nodeWithMostFreeMemory()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	nextid, err := c.GetNextID(0)
+	if err != nil {
+		return nil, err
+	}
+	vmr = NewVmRef(nextid)
+	vmr.SetNode(node)
+	err = config.CloneVm(sourceVmr, vmr, c)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
@@ -0,0 +1,91 @@
+package proxmox
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+// Defaults of the retries of transient lock errors, see Configuration.TransientRetries.
+const (
+	DefaultTransientRetries = 3
+	DefaultTransientBackoff = time.Second
+)
+
+// Lock contention errors of parallel operations, the operation was not done and can be sent again:
+// `can't lock file '/var/lock/qemu-server/lock-100.conf' - got timeout` and
+// `cfs-lock 'file-replication_cfg' error: got lock request timeout`.
+var rxTransientError = regexp.MustCompile(`can't lock file .*got timeout|cfs-lock .*got lock request timeout|unable to acquire lock`)
+
+// IsTransient - is err a lock contention error that retrying after a delay usually resolves?
+func IsTransient(err error) bool {
+	var apiErr *ApiError
+	if errors.As(err, &apiErr) {
+		return rxTransientError.MatchString(apiErr.Message)
+	}
+	var taskErr *ErrTransientTask
+	return errors.As(err, &taskErr)
+}
+
+// ErrTransientTask - a task kept failing on lock contention after all retries.
+type ErrTransientTask struct {
+	ExitStatus string
+}
+
+func (e *ErrTransientTask) Error() string {
+	return "task failed: " + e.ExitStatus
+}
+
+// transientRetry - retries with exponential backoff, zero retries disables them.
+type transientRetry struct {
+	retries int
+	backoff time.Duration
+}
+
+func newTransientRetry(retries int, backoff time.Duration) transientRetry {
+	if retries == 0 {
+		retries = DefaultTransientRetries
+	}
+	if retries < 0 {
+		retries = 0
+	}
+	if backoff <= 0 {
+		backoff = DefaultTransientBackoff
+	}
+	return transientRetry{retries: retries, backoff: backoff}
+}
+
+// wait - sleep before retry number attempt (0 based), false when retries are exhausted.
+func (r transientRetry) wait(attempt int) bool {
+	if attempt >= r.retries {
+		return false
+	}
+	time.Sleep(r.backoff << attempt)
+	return true
+}
+
+// retryTransientTask - start a task and wait for it. Starting it is sent again while it fails on
+// lock contention, or before the request was processed (see isSafeToRetry). Once started the task
+// is waited for only once, failures to poll it are returned as the task may still be running. The
+// task is only started again when its own exit status is a lock contention error, it then did
+// nothing. A task still failing on lock contention after the retries is reported as ErrTransientTask.
+func (c *Client) retryTransientTask(start func() (taskResponse map[string]interface{}, err error)) (exitStatus string, err error) {
+	for attempt := 0; ; attempt++ {
+		var taskResponse map[string]interface{}
+		taskResponse, err = start()
+		if err != nil {
+			if (!IsTransient(err) && !isSafeToRetry(err)) || !c.session.transient.wait(attempt) {
+				return "", err
+			}
+			continue
+		}
+		exitStatus, err = c.WaitForCompletion(taskResponse)
+		// Failed tasks come with their exit status, polling errors without.
+		if !rxTransientError.MatchString(exitStatus) {
+			return
+		}
+		if !c.session.transient.wait(attempt) {
+			return exitStatus, &ErrTransientTask{ExitStatus: exitStatus}
+		}
+	}
+}
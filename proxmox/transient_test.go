@@ -0,0 +1,82 @@
+package proxmox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusChangeVmRetries(t *testing.T) {
+	const upid = "UPID:pve:0000A1B2:00C0FFEE:66000000:qmstart:100:root@pam:"
+	const lockTimeout = "can't lock file '/var/lock/qemu-server/lock-100.conf' - got timeout"
+	for _, test := range []struct {
+		name string
+		// Status codes answered to the start requests, then 200.
+		startCodes []int
+		// Exit status of each started task, polling fails with 503 when empty.
+		exitStatuses   []string
+		wantStarts     int
+		wantExitStatus string
+		wantErr        bool
+	}{
+		{"started once", nil, []string{"OK"}, 1, "OK", false},
+		{"start refused then accepted", []int{http.StatusServiceUnavailable}, []string{"OK"}, 2, "OK", false},
+		{"task failed on lock contention", nil, []string{lockTimeout, "OK"}, 2, "OK", false},
+		{"task failed", nil, []string{"command failed"}, 1, "command failed", true},
+		{"polling fails", nil, []string{""}, 1, "", true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			starts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == "POST" && r.URL.Path == "/nodes/pve/qemu/100/status/start":
+					starts++
+					if starts <= len(test.startCodes) {
+						w.WriteHeader(test.startCodes[starts-1])
+						return
+					}
+					w.Write([]byte(`{"data":"` + upid + `"}`))
+				case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/nodes/pve/tasks/"):
+					started := starts - len(test.startCodes)
+					exitStatus := test.exitStatuses[len(test.exitStatuses)-1]
+					if started <= len(test.exitStatuses) {
+						exitStatus = test.exitStatuses[started-1]
+					}
+					if exitStatus == "" {
+						w.WriteHeader(http.StatusServiceUnavailable)
+						return
+					}
+					w.Write([]byte(`{"data":{"status":"stopped","exitstatus":"` + exitStatus + `"}}`))
+				default:
+					t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			client, err := NewClient(&Configuration{
+				Url:                 server.URL,
+				TaskPollMinInterval: time.Millisecond,
+				TransientBackoff:    time.Millisecond,
+			}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			vmr := NewVmRef(100)
+			vmr.SetNode("pve")
+			vmr.SetVmType("qemu")
+			exitStatus, err := client.StartVm(vmr)
+			if test.wantErr != (err != nil) {
+				t.Errorf("err = %v, want error %t", err, test.wantErr)
+			}
+			if exitStatus != test.wantExitStatus {
+				t.Errorf("exit status = %q, want %q", exitStatus, test.wantExitStatus)
+			}
+			if starts != test.wantStarts {
+				t.Errorf("task started %d times, want %d", starts, test.wantStarts)
+			}
+		})
+	}
+}
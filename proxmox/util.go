@@ -1,10 +1,24 @@
 package proxmox
 
 import (
+	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
 )
 
+// ApiPath - API path from its segments, each one percent-encoded so names containing
+// slashes or other reserved characters (volids, UPIDs) stay a single segment:
+// `ApiPath("nodes", node, "storage", storage, "content", volid)`.
+func ApiPath(segments ...interface{}) string {
+	var path strings.Builder
+	for _, segment := range segments {
+		path.WriteString("/")
+		path.WriteString(url.PathEscape(fmt.Sprint(segment)))
+	}
+	return path.String()
+}
+
 func inArray(arr []string, str string) bool {
 	for _, elem := range arr {
 		if elem == str {
@@ -0,0 +1,22 @@
+package proxmox
+
+import "testing"
+
+func TestApiPath(t *testing.T) {
+	for _, test := range []struct {
+		segments []interface{}
+		want     string
+	}{
+		{nil, ""},
+		{[]interface{}{"version"}, "/version"},
+		{[]interface{}{"nodes", "pve", "qemu", 100, "config"}, "/nodes/pve/qemu/100/config"},
+		{[]interface{}{"access", "users", "john@pve"}, "/access/users/john@pve"},
+		{[]interface{}{"pools", "team/a"}, "/pools/team%2Fa"},
+		{[]interface{}{"cluster", "ha", "resources", "vm:100"}, "/cluster/ha/resources/vm:100"},
+		{[]interface{}{"storage", "a b?c#d"}, "/storage/a%20b%3Fc%23d"},
+	} {
+		if got := ApiPath(test.segments...); got != test.want {
+			t.Errorf("ApiPath(%v) = %q, want %q", test.segments, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package proxmox
+
+import (
+	"math"
+	"net/url"
+	"sort"
+)
+
+// RRD timeframes accepted by the rrddata endpoints.
+const (
+	RrdHour  = "hour"
+	RrdDay   = "day"
+	RrdWeek  = "week"
+	RrdMonth = "month"
+	RrdYear  = "year"
+)
+
+// NodeRrdPoint - one sample of node statistics, Cpu is the utilization between 0 and 1.
+type NodeRrdPoint struct {
+	Time     FlexInt   `json:"time"`
+	Cpu      FlexFloat `json:"cpu"`
+	MaxCpu   FlexInt   `json:"maxcpu"`
+	IoWait   FlexFloat `json:"iowait"`
+	LoadAvg  FlexFloat `json:"loadavg"`
+	MemUsed  FlexFloat `json:"memused"`
+	MemTotal FlexFloat `json:"memtotal"`
+	NetIn    FlexFloat `json:"netin"`
+	NetOut   FlexFloat `json:"netout"`
+}
+
+// GetNodeRrdData - Get the averaged statistics of node over timeframe (RrdHour, RrdDay...).
+// Samples the node did not record have all fields zero but Time.
+func (c *Client) GetNodeRrdData(node string, timeframe string) (points []NodeRrdPoint, err error) {
+	params := url.Values{"timeframe": {timeframe}, "cf": {"AVERAGE"}}
+	return getApiDataWithParams[[]NodeRrdPoint](c, ApiPath("nodes", node, "rrddata"), params)
+}
+
+// NodeUtilization - current and recent utilization of a node, ratios are between 0 and 1.
+// Percentiles are computed over the RRD samples of the timeframe.
+type NodeUtilization struct {
+	Node      string
+	CpuModel  string
+	Cpus      int
+	Cpu       float64
+	CpuP50    float64
+	CpuP95    float64
+	Memory    float64
+	MemP50    float64
+	MemP95    float64
+	IoWaitP95 float64
+	Samples   int
+}
+
+// percentile - nearest-rank percentile p (0-100) of values, which are sorted in place.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	rank := int(math.Ceil(p / 100 * float64(len(values))))
+	if rank < 1 {
+		rank = 1
+	}
+	return values[rank-1]
+}
+
+// GetNodeUtilization - Combine the status of node with its RRD data over timeframe.
+func (c *Client) GetNodeUtilization(node string, timeframe string) (utilization *NodeUtilization, err error) {
+	status, err := c.GetNodeStatus(node)
+	if err != nil {
+		return nil, err
+	}
+	points, err := c.GetNodeRrdData(node, timeframe)
+	if err != nil {
+		return nil, err
+	}
+	utilization = &NodeUtilization{
+		Node:     node,
+		CpuModel: status.CpuInfo.Model,
+		Cpus:     int(status.CpuInfo.Cpus),
+		Cpu:      float64(status.Cpu),
+	}
+	if status.Memory.Total > 0 {
+		utilization.Memory = float64(status.Memory.Used) / float64(status.Memory.Total)
+	}
+	var cpu, memory, ioWait []float64
+	for _, point := range points {
+		// Skip samples the node did not record (offline, rebooting).
+		if point.MaxCpu == 0 && point.MemTotal == 0 {
+			continue
+		}
+		cpu = append(cpu, float64(point.Cpu))
+		ioWait = append(ioWait, float64(point.IoWait))
+		if point.MemTotal > 0 {
+			memory = append(memory, float64(point.MemUsed/point.MemTotal))
+		}
+	}
+	utilization.Samples = len(cpu)
+	utilization.CpuP50 = percentile(cpu, 50)
+	utilization.CpuP95 = percentile(cpu, 95)
+	utilization.MemP50 = percentile(memory, 50)
+	utilization.MemP95 = percentile(memory, 95)
+	utilization.IoWaitP95 = percentile(ioWait, 95)
+	return
+}
+
+// GetClusterUtilization - utilization of every online node over timeframe, least loaded (95th
+// percentile of CPU) first, to place new guests away from hot nodes.
+func (c *Client) GetClusterUtilization(timeframe string) (utilizations []*NodeUtilization, err error) {
+	nodes, err := c.getOnlineNodes()
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		utilization, err := c.GetNodeUtilization(node, timeframe)
+		if err != nil {
+			return nil, err
+		}
+		utilizations = append(utilizations, utilization)
+	}
+	sort.SliceStable(utilizations, func(i, j int) bool {
+		return utilizations[i].CpuP95 < utilizations[j].CpuP95
+	})
+	return
+}
@@ -0,0 +1,27 @@
+package proxmox
+
+import "testing"
+
+func TestPercentile(t *testing.T) {
+	for _, test := range []struct {
+		values []float64
+		p      float64
+		want   float64
+	}{
+		{nil, 95, 0},
+		{[]float64{7}, 50, 7},
+		{[]float64{7}, 0, 7},
+		{[]float64{3, 1, 2}, 0, 1},
+		{[]float64{3, 1, 2}, 50, 2},
+		{[]float64{3, 1, 2}, 100, 3},
+		{[]float64{4, 1, 3, 2}, 50, 2},
+		{[]float64{4, 1, 3, 2}, 51, 3},
+		{[]float64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}, 95, 100},
+		{[]float64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}, 90, 90},
+	} {
+		values := append([]float64{}, test.values...)
+		if got := percentile(values, test.p); got != test.want {
+			t.Errorf("percentile(%v, %v) = %v, want %v", test.values, test.p, got, test.want)
+		}
+	}
+}
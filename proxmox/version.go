@@ -0,0 +1,91 @@
+package proxmox
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Version - Proxmox VE release as returned by /version.
+// PVE 6 reports `version: 6.4, release: 13` while PVE 7+ reports `version: 7.4-3, release: 7.4`,
+// Major and Minor are parsed from whichever shape is returned.
+type Version struct {
+	Version string `json:"version"`
+	Release string `json:"release"`
+	RepoId  string `json:"repoid"`
+	Major   int    `json:"major"`
+	Minor   int    `json:"minor"`
+}
+
+// Capabilities - API features whose availability depends on the PVE version.
+type Capabilities struct {
+	RebootEndpoint     bool `json:"reboot"`         // POST /status/reboot, PVE 6.1+
+	RemoteMigrate      bool `json:"remote_migrate"` // POST /remote_migrate, PVE 7.3+
+	CloudInitPending   bool `json:"cloudinit"`      // GET /cloudinit, PVE 7.3+
+	ImportFrom         bool `json:"import_from"`    // import-from disk option, PVE 7.2+
+	DirectoryMappings  bool `json:"dir_mappings"`   // /cluster/mapping/dir and virtiofs, PVE 8.4+
+	NotificationTarget bool `json:"notif_targets"`  // /cluster/notifications, PVE 8.1+
+	ApiTokens          bool `json:"api_tokens"`     // /access/users/{userid}/token, PVE 6.2+
+}
+
+var rxVersion = regexp.MustCompile(`^(\d+)\.(\d+)`)
+
+// AtLeast - is this version greater than or equal to major.minor?
+func (v Version) AtLeast(major int, minor int) bool {
+	return v.Major > major || (v.Major == major && v.Minor >= minor)
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// Capabilities - capability matrix for this version.
+func (v Version) Capabilities() Capabilities {
+	return Capabilities{
+		RebootEndpoint:     v.AtLeast(6, 1),
+		RemoteMigrate:      v.AtLeast(7, 3),
+		CloudInitPending:   v.AtLeast(7, 3),
+		ImportFrom:         v.AtLeast(7, 2),
+		DirectoryMappings:  v.AtLeast(8, 4),
+		NotificationTarget: v.AtLeast(8, 1),
+		ApiTokens:          v.AtLeast(6, 2),
+	}
+}
+
+// GetVersion - Get the PVE version of the cluster, the result is cached on the client.
+func (c *Client) GetVersion() (version *Version, err error) {
+	c.versionMutex.Lock()
+	defer c.versionMutex.Unlock()
+	if c.version != nil {
+		return c.version, nil
+	}
+	versionData, err := getApiData[map[string]interface{}](c, "/version")
+	if err != nil {
+		return nil, err
+	}
+	if versionData == nil {
+		return nil, errors.New("Version not readable")
+	}
+	version = &Version{}
+	version.Version, _ = versionData["version"].(string)
+	version.Release, _ = versionData["release"].(string)
+	version.RepoId, _ = versionData["repoid"].(string)
+	match := rxVersion.FindStringSubmatch(version.Version)
+	if match == nil {
+		return nil, fmt.Errorf("cannot parse PVE version '%s'", version.Version)
+	}
+	version.Major, _ = strconv.Atoi(match[1])
+	version.Minor, _ = strconv.Atoi(match[2])
+	c.version = version
+	return
+}
+
+// GetCapabilities - capability matrix of the cluster, used to choose compatible request shapes.
+func (c *Client) GetCapabilities() (caps Capabilities, err error) {
+	version, err := c.GetVersion()
+	if err != nil {
+		return Capabilities{}, err
+	}
+	return version.Capabilities(), nil
+}
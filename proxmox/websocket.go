@@ -0,0 +1,316 @@
+package proxmox
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Minimal websocket client (RFC 6455) for the console endpoints, only binary messages are supported.
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+type websocketConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	// Unread payload of the current data frame.
+	remaining uint64
+	// Bound of each frame write, unless a deadline is set.
+	writeTimeout time.Duration
+	deadline     time.Time
+}
+
+// DialWebsocket - open a websocket on an API path (with its query string), authenticated with the session.
+// Connecting and the handshake are bounded by the session Timeout and go through the proxy of the
+// session transport. Writes time out after Timeout as well, reads wait for data: the returned
+// connection also implements SetDeadline(time.Time) error to bound them.
+func (s *Session) DialWebsocket(path string, protocol string) (ws io.ReadWriteCloser, err error) {
+	wsUrl, err := url.Parse(s.ApiUrl + path)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, 16)
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req, err := http.NewRequest("GET", wsUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := s.dialWebsocket(req)
+	if err != nil {
+		return nil, err
+	}
+	// Bound the handshake, the deadline is lifted once the websocket is open.
+	if s.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(s.Timeout))
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if protocol != "" {
+		req.Header.Set("Sec-WebSocket-Protocol", protocol)
+	}
+	s.setAuthHeaders(req)
+	s.setSessionHeaders(req)
+	err = req.Write(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		return nil, &ApiError{Code: resp.StatusCode, Message: resp.Status}
+	}
+	accept := sha1.Sum([]byte(key + websocketGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(accept[:]) {
+		conn.Close()
+		return nil, errors.New("invalid websocket handshake")
+	}
+	conn.SetDeadline(time.Time{})
+	return &websocketConn{conn: conn, reader: reader, writeTimeout: s.Timeout}, nil
+}
+
+// dialWebsocket - connection to the host of req, through the proxy of the session transport when
+// it has one for req, TLS for https URLs. Ports default to 443 for https and 80 for http.
+func (s *Session) dialWebsocket(req *http.Request) (conn net.Conn, err error) {
+	wsUrl := req.URL
+	host := wsUrl.Host
+	if wsUrl.Port() == "" {
+		port := "443"
+		if wsUrl.Scheme == "http" {
+			port = "80"
+		}
+		host = net.JoinHostPort(wsUrl.Hostname(), port)
+	}
+	// A zero session timeout does not limit the dial either.
+	ctx := context.Background()
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	dialer := &net.Dialer{Timeout: s.Timeout}
+	dial := dialer.DialContext
+	tlsConfig := &tls.Config{}
+	var proxyUrl *url.URL
+	if tr, ok := s.httpClient.Transport.(*http.Transport); ok {
+		if tr.DialContext != nil {
+			dial = tr.DialContext
+		}
+		if tr.TLSClientConfig != nil {
+			tlsConfig = tr.TLSClientConfig.Clone()
+		}
+		if tr.Proxy != nil {
+			proxyUrl, err = tr.Proxy(req)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if proxyUrl == nil {
+		conn, err = dial(ctx, "tcp", host)
+	} else {
+		conn, err = dialProxyTunnel(ctx, dial, proxyUrl, host)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if wsUrl.Scheme != "https" {
+		return conn, nil
+	}
+	tlsConfig.ServerName = wsUrl.Hostname()
+	tlsConn := tls.Client(conn, tlsConfig)
+	err = tlsConn.HandshakeContext(ctx)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// dialProxyTunnel - connection to host tunneled through an HTTP proxy with CONNECT.
+func dialProxyTunnel(ctx context.Context, dial func(ctx context.Context, network string, addr string) (net.Conn, error), proxyUrl *url.URL, host string) (conn net.Conn, err error) {
+	proxyHost := proxyUrl.Host
+	if proxyUrl.Port() == "" {
+		proxyHost = net.JoinHostPort(proxyUrl.Hostname(), "80")
+	}
+	conn, err = dial(ctx, "tcp", proxyHost)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	connectReq := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: host},
+		Host:   host,
+		Header: http.Header{},
+	}
+	if proxyUrl.User != nil {
+		password, _ := proxyUrl.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyUrl.User.Username() + ":" + password))
+		connectReq.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	err = connectReq.Write(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// Nothing follows the response until the tunnel is used, the reader buffers nothing past it.
+	resp, err := http.ReadResponse(bufio.NewReader(conn), connectReq)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT to %s failed: %s", host, resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// SetDeadline - bound reads and writes of the websocket, the zero time removes the bound.
+func (ws *websocketConn) SetDeadline(t time.Time) error {
+	ws.deadline = t
+	return ws.conn.SetDeadline(t)
+}
+
+// Read - payload of data frames, control frames are handled transparently.
+func (ws *websocketConn) Read(p []byte) (n int, err error) {
+	for ws.remaining == 0 {
+		opcode, length, err := ws.readFrameHeader()
+		if err != nil {
+			return 0, err
+		}
+		switch opcode {
+		case wsOpBinary, wsOpText, wsOpContinuation:
+			ws.remaining = length
+		case wsOpClose:
+			return 0, io.EOF
+		case wsOpPing:
+			payload := make([]byte, length)
+			_, err = io.ReadFull(ws.reader, payload)
+			if err == nil {
+				err = ws.writeFrame(wsOpPong, payload)
+			}
+			if err != nil {
+				return 0, err
+			}
+		default:
+			_, err = io.CopyN(io.Discard, ws.reader, int64(length))
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	if uint64(len(p)) > ws.remaining {
+		p = p[:ws.remaining]
+	}
+	n, err = ws.reader.Read(p)
+	ws.remaining -= uint64(n)
+	return
+}
+
+func (ws *websocketConn) readFrameHeader() (opcode byte, length uint64, err error) {
+	header := make([]byte, 2)
+	_, err = io.ReadFull(ws.reader, header)
+	if err != nil {
+		return 0, 0, err
+	}
+	opcode = header[0] & 0x0f
+	length = uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		_, err = io.ReadFull(ws.reader, extended)
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		_, err = io.ReadFull(ws.reader, extended)
+		length = binary.BigEndian.Uint64(extended)
+	}
+	if err == nil && header[1]&0x80 != 0 {
+		return 0, 0, errors.New("websocket server frames must not be masked")
+	}
+	return
+}
+
+// Write - send p as a single binary frame.
+func (ws *websocketConn) Write(p []byte) (n int, err error) {
+	err = ws.writeFrame(wsOpBinary, p)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (ws *websocketConn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	length := len(payload)
+	switch {
+	case length < 126:
+		frame = append(frame, 0x80|byte(length))
+	case length <= 0xffff:
+		frame = append(frame, 0x80|126, byte(length>>8), byte(length))
+	default:
+		extended := make([]byte, 8)
+		binary.BigEndian.PutUint64(extended, uint64(length))
+		frame = append(append(frame, 0x80|127), extended...)
+	}
+	mask := make([]byte, 4)
+	_, err := rand.Read(mask)
+	if err != nil {
+		return err
+	}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if ws.deadline.IsZero() && ws.writeTimeout > 0 {
+		ws.conn.SetWriteDeadline(time.Now().Add(ws.writeTimeout))
+	}
+	_, err = ws.conn.Write(frame)
+	return err
+}
+
+func (ws *websocketConn) Close() error {
+	ws.writeFrame(wsOpClose, nil)
+	return ws.conn.Close()
+}
@@ -0,0 +1,41 @@
+package proxmox
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDialWebsocketTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + websocketGUID))
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+		buf.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(accept[:]) + "\r\n\r\n")
+		buf.Flush()
+	}))
+	defer server.Close()
+
+	for _, timeout := range []time.Duration{0, 5 * time.Second} {
+		session, err := NewSession(&Configuration{Url: server.URL}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Zero means no limit, like for the other requests.
+		session.Timeout = timeout
+		ws, err := session.DialWebsocket("/nodes/pve/qemu/100/vncwebsocket", "binary")
+		if err != nil {
+			t.Errorf("timeout %s: %s", timeout, err)
+			continue
+		}
+		ws.Close()
+	}
+}